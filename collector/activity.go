@@ -1,7 +1,9 @@
 package collector
 
 import (
+	"context"
 	"database/sql"
+	"strconv"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -47,10 +49,12 @@ const (
 		query,
 		wait_event_type,
 		rsgname,
+		coalesce(state, 'unknown') as state,
+		round(extract(epoch FROM (now() - xact_start))) as xact_age,
 		count(*)::float 
 		from pg_stat_activity
 		where pid <> pg_backend_pid()
-		group by datname,pid,sess_id,usename,application_name,client_addr,start_time,backend_start,duration,wait_event,query,wait_event_type,rsgname
+		group by datname,pid,sess_id,usename,application_name,client_addr,start_time,backend_start,duration,wait_event,query,wait_event_type,rsgname,state,xact_age
 		order by start_time;
 	`
 	pgActivitySql_v5 = `
@@ -69,39 +73,144 @@ const (
 			current_query,
 			waiting_reason,
 			rsgname,
+			case
+				when current_query = '<IDLE>' then 'idle'
+				when current_query like '<IDLE> in transaction%' then 'idle in transaction'
+				else 'active'
+			end as state,
+			round(extract(epoch FROM (now() - xact_start))) as xact_age,
 			count(*)::float
 		from pg_stat_activity
 		where procpid <> pg_backend_pid()
-		group by datname,procpid,sess_id,usename,application_name,client_addr,start_time,backend_start,duration,waiting,current_query,waiting_reason,rsgname
+		group by datname,procpid,sess_id,usename,application_name,client_addr,start_time,backend_start,duration,waiting,current_query,waiting_reason,rsgname,state,xact_age
 		order by start_time;`
 )
 
 var (
-	activityDesc = prometheus.NewDesc(
+	activityDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemServer, "activity_detail"),
 		"Processes detail for HashData database",
-		[]string{"datname", "pid", "sess_id", "usename", "application_name", "client_addr", "backend_start", "start_time", "duration", "wait_event", "query", "wait_event_type", "rsgname"},
+		[]string{"datname", "pid", "sess_id", "usename", "application_name", "client_addr", "backend_start", "start_time", "duration", "wait_event", "query", "query_hash", "wait_event_type", "rsgname"},
 		nil,
 	)
+
+	activitySessionsDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "activity_sessions"),
+		"Number of sessions by database, user, resource group, state and wait event type",
+		[]string{"datname", "usename", "rsgname", "state", "wait_event_type"},
+		nil,
+	)
+
+	activityQueryDurationDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "activity_query_duration_seconds"),
+		"Histogram of the running time of active queries by resource group",
+		[]string{"rsgname"},
+		nil,
+	)
+
+	activityQueryDurationMaxDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "activity_query_duration_max_seconds"),
+		"Running time of the longest active query by resource group",
+		[]string{"rsgname"},
+		nil,
+	)
+
+	activityXactAgeDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "activity_transaction_age_seconds"),
+		"Histogram of the age of open transactions by resource group",
+		[]string{"rsgname"},
+		nil,
+	)
+
+	activityXactAgeMaxDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "activity_transaction_age_max_seconds"),
+		"Age of the oldest open transaction by resource group",
+		[]string{"rsgname"},
+		nil,
+	)
+
+	// 查询时长和事务时长直方图的分桶，单位为秒.
+	activityDurationBuckets = []float64{1, 5, 30, 60, 300, 600, 1800, 3600, 7200, 21600}
 )
 
+// 会话数统计的标签组合.
+type activitySessionKey struct {
+	datname, usename, rsgname, state, waitEventType string
+}
+
+// 时长分布，用于输出直方图和最大值.
+type durationStats struct {
+	count   uint64
+	sum     float64
+	max     float64
+	buckets map[float64]uint64
+}
+
+/**
+* 函数：observe
+* 功能：记录一个时长，buckets为累计计数
+ */
+func (d *durationStats) observe(seconds float64) {
+	if d.buckets == nil {
+		d.buckets = make(map[float64]uint64, len(activityDurationBuckets))
+		for _, bound := range activityDurationBuckets {
+			d.buckets[bound] = 0
+		}
+	}
+
+	d.count++
+	d.sum += seconds
+	if seconds > d.max {
+		d.max = seconds
+	}
+
+	for _, bound := range activityDurationBuckets {
+		if seconds <= bound {
+			d.buckets[bound]++
+		}
+	}
+}
+
+/**
+* 函数：collectDurations
+* 功能：按资源组输出时长直方图和最大值
+ */
+func collectDurations(ch chan<- prometheus.Metric, histogramDesc, maxDesc *prometheus.Desc, stats map[string]*durationStats) {
+	for rsgname, d := range stats {
+		ch <- prometheus.MustNewConstHistogram(histogramDesc, d.count, d.sum, d.buckets, rsgname)
+		ch <- prometheus.MustNewConstMetric(maxDesc, prometheus.GaugeValue, d.max, rsgname)
+	}
+}
+
 func NewActivityScraper() Scraper {
 	return &activityScraper{}
 }
 
-type activityScraper struct{}
+type activityScraper struct {
+	baseScraper
+}
 
 func (activityScraper) Name() string {
 	return "activityScraper"
 }
 
-func (activityScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+func (s *activityScraper) Configure(opts ScraperOptions) error {
+	if err := opts.check(supportedOptions{detailMetrics: true}); err != nil {
+		return err
+	}
+
+	s.opts = opts
+
+	return nil
+}
+
+func (s *activityScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
 	activitySql := pgActivitySql_v6
 	if ver > 3 && ver < 6 {
 		activitySql = pgActivitySql_v5
 	}
 
-	rows, err := db.Query(activitySql)
+	rows, err := db.QueryContext(ctx, activitySql)
 	logger.Infof("Query Database: %s", activitySql)
 
 	if err != nil {
@@ -110,9 +219,16 @@ func (activityScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver int)
 
 	defer rows.Close()
 
+	details := make([]DetailRow, 0)
+	guard := s.env.guard(s.Name(), s.opts)
+	sessions := make(map[activitySessionKey]float64)
+	queryDurations := make(map[string]*durationStats)
+	xactAges := make(map[string]*durationStats)
+
 	for rows.Next() {
-		var pid, sess_id, application_name, query, rsgname string
+		var pid, sess_id, application_name, query, rsgname, state string
 		var datname, usename, start_time, backend_start, client_addr, duration, wait_event, wait_event_type sql.NullString
+		var xact_age sql.NullFloat64
 		var currentTime time.Time
 		var count int64
 		err = rows.Scan(&currentTime,
@@ -122,20 +238,58 @@ func (activityScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver int)
 			&usename,
 			&application_name,
 			&client_addr,
-			&start_time,
 			&backend_start,
+			&start_time,
 			&duration,
 			&wait_event,
 			&query,
 			&wait_event_type,
 			&rsgname,
+			&state,
+			&xact_age,
 			&count)
 
 		if err != nil {
 			return err
 		}
 
-		ch <- prometheus.MustNewConstMetric(activityDesc, prometheus.GaugeValue,
+		fingerprint, queryHash := fingerprintQuery(query)
+
+		sessions[activitySessionKey{datname.String, usename.String, rsgname, state, wait_event_type.String}]++
+
+		if seconds, err := strconv.ParseFloat(duration.String, 64); err == nil && state == "active" {
+			observeDuration(queryDurations, rsgname, seconds)
+		}
+
+		if xact_age.Valid {
+			observeDuration(xactAges, rsgname, xact_age.Float64)
+		}
+
+		details = append(details, DetailRow{
+			"datname":          datname.String,
+			"pid":              pid,
+			"sess_id":          sess_id,
+			"usename":          usename.String,
+			"application_name": application_name,
+			"client_addr":      client_addr.String,
+			"backend_start":    backend_start.String,
+			"start_time":       start_time.String,
+			"duration":         duration.String,
+			"wait_event":       wait_event.String,
+			"query":            query,
+			"fingerprint":      fingerprint,
+			"query_hash":       queryHash,
+			"wait_event_type":  wait_event_type.String,
+			"rsgname":          rsgname,
+			"state":            state,
+			"xact_age":         nullFloatString(xact_age),
+		})
+
+		if !s.opts.detailMetrics() {
+			continue
+		}
+
+		guard.emit(ch, activityDesc,
 			float64(currentTime.UTC().Unix()),
 			datname.String,
 			pid,
@@ -143,14 +297,47 @@ func (activityScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver int)
 			usename.String,
 			application_name,
 			client_addr.String,
-			start_time.String,
 			backend_start.String,
+			start_time.String,
 			duration.String,
 			wait_event.String,
-			query,
+			fingerprint,
+			queryHash,
 			wait_event_type.String,
 			rsgname)
 	}
 
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for key, count := range sessions {
+		ch <- prometheus.MustNewConstMetric(activitySessionsDesc, prometheus.GaugeValue, count,
+			key.datname, key.usename, key.rsgname, key.state, key.waitEventType)
+	}
+
+	collectDurations(ch, activityQueryDurationDesc, activityQueryDurationMaxDesc, queryDurations)
+	collectDurations(ch, activityXactAgeDesc, activityXactAgeMaxDesc, xactAges)
+
+	s.env.publish(s.Name(), DatasetActivity, details)
+
 	return nil
 }
+
+func observeDuration(stats map[string]*durationStats, rsgname string, seconds float64) {
+	d, ok := stats[rsgname]
+	if !ok {
+		d = &durationStats{}
+		stats[rsgname] = d
+	}
+
+	d.observe(seconds)
+}
+
+func nullFloatString(v sql.NullFloat64) string {
+	if !v.Valid {
+		return ""
+	}
+
+	return strconv.FormatFloat(v.Float64, 'f', -1, 64)
+}
@@ -0,0 +1,247 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	logger "github.com/prometheus/common/log"
+)
+
+/**
+ * AO表抓取器：gp_toolkit.gp_bloat_diag只统计heap表，追加优化表（AO/AOCO）的删除和更新只在visimap中标记为隐藏，
+ * 需要VACUUM压缩segment文件才能回收空间。对每个AO/AOCO表调用gp_toolkit.__gp_aovisimap_compaction_info
+ * 输出隐藏元组比例、segment文件数以及可以压缩的文件数，V6及以上调用__gp_aoseg/__gp_aocsseg输出文件大小和等待删除的文件数
+ */
+
+const (
+	// V5、V6通过relstorage区分AO行存（a）和列存（c）.
+	aoTablesSql = `
+	select c.oid, n.nspname, c.relname, c.relstorage = 'c' as columnstore
+	from pg_class c join pg_namespace n on n.oid = c.relnamespace
+	where c.relkind = 'r' and c.relstorage in ('a', 'c')
+	and n.nspname not in ('pg_catalog', 'information_schema', 'gp_toolkit')
+	and n.nspname not like 'pg_temp%';`
+
+	// V7通过表访问方法区分AO行存和列存.
+	aoTablesSqlV7 = `
+	select c.oid, n.nspname, c.relname, am.amname = 'ao_column' as columnstore
+	from pg_class c join pg_namespace n on n.oid = c.relnamespace join pg_am am on am.oid = c.relam
+	where c.relkind = 'r' and am.amname in ('ao_row', 'ao_column')
+	and n.nspname not in ('pg_catalog', 'information_schema', 'gp_toolkit')
+	and n.nspname not like 'pg_temp%';`
+
+	// 每行是一个segment上的一个segment文件，compaction_possible表示隐藏元组比例超过gp_appendonly_compaction_threshold.
+	aoCompactionInfoSql = `
+	select count(*)::float8,
+		count(case when compaction_possible then 1 end)::float8,
+		coalesce(sum(hidden_tupcount), 0)::float8,
+		coalesce(sum(total_tupcount), 0)::float8
+	from gp_toolkit.__gp_aovisimap_compaction_info($1::oid);`
+
+	// %s为__gp_aoseg或__gp_aocsseg，列存的每个segment文件每列一行，state为2表示压缩后等待删除.
+	aoSegSql = `
+	select coalesce(sum(eof), 0)::float8,
+		count(distinct case when state = 2 then segment_id || '.' || segno end)::float8
+	from gp_toolkit.%s($1::oid::regclass);`
+)
+
+var aoTableLabels = []string{"datname", "schema_name", "table_name", "storage"}
+
+var (
+	aoHiddenTuplesDesc = newAOTableDesc("ao_table_hidden_tuples",
+		"Number of tuples hidden by deletes and updates in the append-optimized table")
+	aoTotalTuplesDesc = newAOTableDesc("ao_table_total_tuples",
+		"Total number of tuples in the segment files of the append-optimized table, including hidden tuples")
+	aoHiddenRatioDesc = newAOTableDesc("ao_table_hidden_ratio",
+		"Ratio of hidden tuples to total tuples of the append-optimized table")
+	aoSegmentFilesDesc = newAOTableDesc("ao_table_segment_files",
+		"Number of segment files of the append-optimized table across all segments")
+	aoCompactableFilesDesc = newAOTableDesc("ao_table_compactable_segment_files",
+		"Number of segment files whose hidden ratio exceeds gp_appendonly_compaction_threshold and will be compacted by VACUUM")
+	aoAwaitingDropFilesDesc = newAOTableDesc("ao_table_awaiting_drop_segment_files",
+		"Number of compacted segment files of the append-optimized table awaiting drop")
+	aoSizeDesc = newAOTableDesc("ao_table_size_bytes",
+		"Size of the segment files of the append-optimized table across all segments")
+)
+
+func newAOTableDesc(name, help string) *prometheus.Desc {
+	return newDesc(prometheus.BuildFQName(namespace, subSystemServer, name), help, aoTableLabels, nil)
+}
+
+func NewAOTableScraper() Scraper {
+	return &aoTableScraper{}
+}
+
+type aoTableScraper struct {
+	baseScraper
+}
+
+// 一个AO/AOCO表.
+type aoTable struct {
+	oid                   int64
+	schemaName, tableName string
+	columnstore           bool
+}
+
+func (aoTableScraper) Name() string {
+	return "ao_table_scraper"
+}
+
+func (s *aoTableScraper) Configure(opts ScraperOptions) error {
+	if err := opts.check(supportedOptions{databases: true, tables: true, limits: true}); err != nil {
+		return err
+	}
+
+	// 每个表的查询都会在所有segment上执行，不允许不加限制地抓取所有AO/AOCO表
+	if len(opts.Tables.Include) == 0 {
+		return errors.New("tables.include must not be empty, only the listed tables are scraped")
+	}
+
+	s.opts = opts
+
+	return nil
+}
+
+func (s *aoTableScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+	names, err := s.env.databases(ctx, db, s.opts.Databases)
+	if err != nil {
+		return err
+	}
+
+	guard := s.env.guard(s.Name(), s.opts)
+	errs := make([]error, 0)
+
+	for _, dbname := range names {
+		if err = s.scrapeDatabase(ctx, dbname, guard, ch, ver); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return combineErr(errs...)
+}
+
+/**
+* 函数：scrapeDatabase
+* 功能：查询一个数据库中的AO/AOCO表，按tables过滤后逐个表输出指标，超出max_rows的表不再查询
+ */
+func (s *aoTableScraper) scrapeDatabase(ctx context.Context, dbname string, guard *seriesGuard, ch chan<- prometheus.Metric, ver int) error {
+	conn, err := s.env.conns.database(dbname)
+	if err != nil {
+		return err
+	}
+
+	tables, err := s.tables(ctx, conn, dbname, ver)
+	if err != nil {
+		return err
+	}
+
+	errs := make([]error, 0)
+
+	for _, t := range tables {
+		if !guard.next() {
+			continue
+		}
+
+		storage := "row"
+		if t.columnstore {
+			storage = "column"
+		}
+
+		labels := guard.labels(dbname, t.schemaName, t.tableName, storage)
+
+		err = s.scrapeTable(ctx, conn, t, labels, ch, ver)
+
+		// 查询期间被删除的表忽略
+		if err != nil && !hasErrorCode(err, undefinedTable) {
+			errs = append(errs, fmt.Errorf("%s.%s.%s: %v", dbname, t.schemaName, t.tableName, err))
+		}
+	}
+
+	return combineErr(errs...)
+}
+
+/**
+* 函数：tables
+* 功能：返回数据库中匹配tables的AO/AOCO表
+ */
+func (s *aoTableScraper) tables(ctx context.Context, conn *sql.DB, dbname string, ver int) ([]aoTable, error) {
+	tablesSql := aoTablesSql
+	if ver >= 7 {
+		tablesSql = aoTablesSqlV7
+	}
+
+	rows, err := conn.QueryContext(ctx, tablesSql)
+	logger.Infof("Query Database: %s on %s", tablesSql, dbname)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	tables := make([]aoTable, 0)
+
+	for rows.Next() {
+		var t aoTable
+		if err = rows.Scan(&t.oid, &t.schemaName, &t.tableName, &t.columnstore); err != nil {
+			return nil, err
+		}
+
+		if !s.opts.Tables.allowed(t.schemaName, t.tableName) {
+			continue
+		}
+
+		tables = append(tables, t)
+	}
+
+	return tables, rows.Err()
+}
+
+/**
+* 函数：scrapeTable
+* 功能：输出一个AO/AOCO表的隐藏元组、segment文件以及文件大小
+ */
+func (s *aoTableScraper) scrapeTable(ctx context.Context, conn *sql.DB, t aoTable, labels []string, ch chan<- prometheus.Metric, ver int) error {
+	var files, compactable, hidden, total float64
+
+	err := conn.QueryRowContext(ctx, aoCompactionInfoSql, t.oid).Scan(&files, &compactable, &hidden, &total)
+	if err != nil {
+		return err
+	}
+
+	ratio := 0.0
+	if total > 0 {
+		ratio = hidden / total
+	}
+
+	ch <- prometheus.MustNewConstMetric(aoHiddenTuplesDesc, prometheus.GaugeValue, hidden, labels...)
+	ch <- prometheus.MustNewConstMetric(aoTotalTuplesDesc, prometheus.GaugeValue, total, labels...)
+	ch <- prometheus.MustNewConstMetric(aoHiddenRatioDesc, prometheus.GaugeValue, ratio, labels...)
+	ch <- prometheus.MustNewConstMetric(aoSegmentFilesDesc, prometheus.GaugeValue, files, labels...)
+	ch <- prometheus.MustNewConstMetric(aoCompactableFilesDesc, prometheus.GaugeValue, compactable, labels...)
+
+	// __gp_aoseg和__gp_aocsseg从V6开始在所有segment上执行
+	if ver < 6 {
+		return nil
+	}
+
+	function := "__gp_aoseg"
+	if t.columnstore {
+		function = "__gp_aocsseg"
+	}
+
+	var size, awaitingDrop float64
+
+	err = conn.QueryRowContext(ctx, fmt.Sprintf(aoSegSql, function), t.oid).Scan(&size, &awaitingDrop)
+	if err != nil {
+		return err
+	}
+
+	ch <- prometheus.MustNewConstMetric(aoSizeDesc, prometheus.GaugeValue, size, labels...)
+	ch <- prometheus.MustNewConstMetric(aoAwaitingDropFilesDesc, prometheus.GaugeValue, awaitingDrop, labels...)
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package collector
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestAOTableScraperTables(t *testing.T) {
+	cases := []struct {
+		name     string
+		ver      int
+		executed string
+		skipped  string
+	}{
+		{"v6 relstorage", 6, "c.relstorage in ('a', 'c')", "pg_am"},
+		{"v7 access method", 7, "am.amname in ('ao_row', 'ao_column')", "relstorage"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			f, db := newFakeDB()
+			f.on("columnstore", []string{"oid", "nspname", "relname", "columnstore"},
+				[]driver.Value{int64(1), "dw", "sales_fact", false},
+				[]driver.Value{int64(2), "dw", "orders_fact", true},
+				[]driver.Value{int64(3), "dw", "tmp_fact", true},
+				[]driver.Value{int64(4), "public", "sales_fact", false},
+			)
+
+			s := &aoTableScraper{}
+			s.opts = ScraperOptions{Tables: TableFilter{Include: []string{"dw\\..*_fact"}, Exclude: []string{"dw\\.tmp_.*"}}}
+
+			tables, err := s.tables(context.Background(), db, "postgres", c.ver)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := []aoTable{
+				{oid: 1, schemaName: "dw", tableName: "sales_fact"},
+				{oid: 2, schemaName: "dw", tableName: "orders_fact", columnstore: true},
+			}
+
+			if !reflect.DeepEqual(tables, expected) {
+				t.Errorf("tables() = %v, expected %v", tables, expected)
+			}
+
+			if f.executed(c.executed) != 1 || f.executed(c.skipped) != 0 {
+				t.Errorf("unexpected queries %v", f.queries)
+			}
+		})
+	}
+}
+
+func TestAOTableScraperScrapeTable(t *testing.T) {
+	cases := []struct {
+		name     string
+		ver      int
+		table    aoTable
+		segQuery string
+		metrics  int
+	}{
+		{"v5 without segment files", 5, aoTable{oid: 1, schemaName: "dw", tableName: "sales_fact"}, "", 5},
+		{"v6 row storage", 6, aoTable{oid: 1, schemaName: "dw", tableName: "sales_fact"}, "gp_toolkit.__gp_aoseg(", 7},
+		{"v7 column storage", 7, aoTable{oid: 2, schemaName: "dw", tableName: "orders_fact", columnstore: true}, "gp_toolkit.__gp_aocsseg(", 7},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			f, db := newFakeDB()
+			f.on("__gp_aovisimap_compaction_info", []string{"files", "compactable", "hidden", "total"},
+				[]driver.Value{4.0, 1.0, 25.0, 100.0})
+			f.on("__gp_ao", []string{"eof", "awaiting_drop"}, []driver.Value{8192.0, 1.0})
+
+			ch := make(chan prometheus.Metric, 10)
+			if err := (&aoTableScraper{}).scrapeTable(context.Background(), db, c.table, []string{"postgres", c.table.schemaName, c.table.tableName, "row"}, ch, c.ver); err != nil {
+				t.Fatal(err)
+			}
+			close(ch)
+
+			metrics := make(metricsCollector, 0)
+			for m := range ch {
+				metrics = append(metrics, m)
+				if m.Desc() == aoHiddenRatioDesc {
+					if ratio := testutil.ToFloat64(metricsCollector{m}); ratio != 0.25 {
+						t.Errorf("hidden ratio = %v, expected 0.25", ratio)
+					}
+				}
+			}
+
+			if len(metrics) != c.metrics {
+				t.Errorf("got %d metrics, expected %d", len(metrics), c.metrics)
+			}
+
+			if c.segQuery == "" {
+				if f.executed("__gp_aoseg") != 0 || f.executed("__gp_aocsseg") != 0 {
+					t.Errorf("segment file functions queried before V6")
+				}
+			} else if f.executed(c.segQuery) != 1 {
+				t.Errorf("query containing %q was not executed", c.segQuery)
+			}
+		})
+	}
+}
+
+func TestAOTableScraperConfigure(t *testing.T) {
+	s := NewAOTableScraper().(*aoTableScraper)
+
+	if err := s.Configure(ScraperOptions{Tables: TableFilter{Exclude: []string{"dw\\.tmp_.*"}}}); err == nil {
+		t.Errorf("expected error without tables.include")
+	}
+
+	if err := s.Configure(ScraperOptions{Tables: TableFilter{Include: []string{"dw\\..*_fact"}}}); err != nil {
+		t.Errorf("Configure() failed: %v", err)
+	}
+}
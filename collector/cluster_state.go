@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"time"
@@ -24,27 +25,27 @@ const (
 )
 
 var (
-	stateDesc = prometheus.NewDesc(
+	stateDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemCluster, "state"),
 		"Whether the HashData database is accessible",
 		[]string{"version", "hdw_version", "master", "standby"},
 		nil,
 	)
 
-	upTimeDesc = prometheus.NewDesc(
+	upTimeDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemCluster, "uptime"),
 		"Duration that the HashData database have been started since last up in second",
 		nil, nil,
 	)
 
-	syncDesc = prometheus.NewDesc(
+	syncDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemCluster, "sync"),
 		"Whether the HashData master node is synchronizing to standby",
 		nil,
 		nil,
 	)
 
-	configLoadTimeDesc = prometheus.NewDesc(
+	configLoadTimeDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemCluster, "config_last_load_time_seconds"),
 		"Timestamp of the last configuration reload",
 		nil,
@@ -56,14 +57,16 @@ func NewClusterStateScraper() Scraper {
 	return &clusterStateScraper{}
 }
 
-type clusterStateScraper struct{}
+type clusterStateScraper struct {
+	baseScraper
+}
 
 func (clusterStateScraper) Name() string {
 	return "cluster_state_scraper"
 }
 
-func (clusterStateScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
-	rows, err := db.Query(checkStateSql)
+func (clusterStateScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+	rows, err := db.QueryContext(ctx, checkStateSql)
 	logger.Infof("Query Database: %s", checkStateSql)
 
 	if err != nil {
@@ -84,13 +87,13 @@ func (clusterStateScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver i
 		}
 	}
 
-	version, errV := scrapeVersion(db)
-	hdwversion, errHV := scrapeHdwVersion(db)
-	master, errM := scrapeMaster(db)
-	standby, errX := scrapeStandby(db)
-	upTime, errU := scrapeUpTime(db)
-	sync, errW := scrapeSync(db)
-	configLoadTime, errY := scrapeConfigLoadTime(db, ver)
+	version, errV := scrapeVersion(ctx, db)
+	hdwversion, errHV := scrapeHdwVersion(ctx, db)
+	master, errM := scrapeMaster(ctx, db)
+	standby, errX := scrapeStandby(ctx, db)
+	upTime, errU := scrapeUpTime(ctx, db)
+	sync, errW := scrapeSync(ctx, db)
+	configLoadTime, errY := scrapeConfigLoadTime(ctx, db, ver)
 
 	ch <- prometheus.MustNewConstMetric(stateDesc, prometheus.GaugeValue, 1, version, hdwversion, master, standby)
 	ch <- prometheus.MustNewConstMetric(upTimeDesc, prometheus.GaugeValue, upTime)
@@ -100,8 +103,8 @@ func (clusterStateScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver i
 	return combineErr(errM, errV, errHV, errU, errW, errX, errY)
 }
 
-func scrapeUpTime(db *sql.DB) (upTime float64, err error) {
-	rows, err := db.Query(upTimeSql)
+func scrapeUpTime(ctx context.Context, db *sql.DB) (upTime float64, err error) {
+	rows, err := db.QueryContext(ctx, upTimeSql)
 	logger.Infof("Query Database Up Time: %s", upTimeSql)
 
 	if err != nil {
@@ -121,8 +124,8 @@ func scrapeUpTime(db *sql.DB) (upTime float64, err error) {
 	return
 }
 
-func scrapeVersion(db *sql.DB) (ver string, err error) {
-	rows, err := db.Query(versionSql)
+func scrapeVersion(ctx context.Context, db *sql.DB) (ver string, err error) {
+	rows, err := db.QueryContext(ctx, versionSql)
 	logger.Infof("Query Database Version: %s", versionSql)
 
 	if err != nil {
@@ -140,8 +143,8 @@ func scrapeVersion(db *sql.DB) (ver string, err error) {
 	return
 }
 
-func scrapeHdwVersion(db *sql.DB) (ver string, err error) {
-	rows, err := db.Query(hdwVersionSql)
+func scrapeHdwVersion(ctx context.Context, db *sql.DB) (ver string, err error) {
+	rows, err := db.QueryContext(ctx, hdwVersionSql)
 	logger.Infof("Query Database Version: %s", hdwVersionSql)
 
 	if err != nil {
@@ -159,8 +162,8 @@ func scrapeHdwVersion(db *sql.DB) (ver string, err error) {
 	return
 }
 
-func scrapeMaster(db *sql.DB) (host string, err error) {
-	rows, err := db.Query(masterNameSql)
+func scrapeMaster(ctx context.Context, db *sql.DB) (host string, err error) {
+	rows, err := db.QueryContext(ctx, masterNameSql)
 	logger.Infof("Query Database Master Name: %s", masterNameSql)
 
 	if err != nil {
@@ -179,8 +182,8 @@ func scrapeMaster(db *sql.DB) (host string, err error) {
 	return
 }
 
-func scrapeStandby(db *sql.DB) (host string, err error) {
-	rows, err := db.Query(standbyNameSql)
+func scrapeStandby(ctx context.Context, db *sql.DB) (host string, err error) {
+	rows, err := db.QueryContext(ctx, standbyNameSql)
 	logger.Infof("Query Database Standby Name: %s", standbyNameSql)
 
 	if err != nil {
@@ -197,8 +200,8 @@ func scrapeStandby(db *sql.DB) (host string, err error) {
 	return
 }
 
-func scrapeSync(db *sql.DB) (sync float64, err error) {
-	rows, err := db.Query(syncSql)
+func scrapeSync(ctx context.Context, db *sql.DB) (sync float64, err error) {
+	rows, err := db.QueryContext(ctx, syncSql)
 	logger.Infof("Query Database Sync : %s", syncSql)
 
 	if err != nil {
@@ -216,13 +219,13 @@ func scrapeSync(db *sql.DB) (sync float64, err error) {
 	return
 }
 
-func scrapeConfigLoadTime(db *sql.DB, ver int) (time time.Time, err error) {
+func scrapeConfigLoadTime(ctx context.Context, db *sql.DB, ver int) (time time.Time, err error) {
 	querySql := configLoadTimeSql_V6
 	if ver > 3 && ver < 6 {
 		querySql = configLoadTimeSql_V5
 	}
 
-	rows, err := db.Query(querySql)
+	rows, err := db.QueryContext(ctx, querySql)
 	logger.Infof("Query Database Config load Time : %s", querySql)
 
 	if err != nil {
@@ -1,65 +1,256 @@
 package collector
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	_ "github.com/lib/pq"
 	"github.com/prometheus/client_golang/prometheus"
-	"hdw-exporter/stopwatch"
 	logger "github.com/prometheus/common/log"
-	"os"
+	"hdw-exporter/stopwatch"
 	"sync"
 	"time"
 )
 
-const verMajorSql=`select (select regexp_matches((select (select regexp_matches((select version()), 'Greenplum Database \d{1,}\.\d{1,}\.\d{1,}'))[1] as version), '\d{1,}'))[1];`
-
+const verMajorSql = `select (select regexp_matches((select (select regexp_matches((select version()), 'Greenplum Database \d{1,}\.\d{1,}\.\d{1,}'))[1] as version), '\d{1,}'))[1];`
 
 type HdwCollector struct {
 	mu sync.Mutex
 
-	db       *sql.DB
-	ver       int
+	db       *sharedDB
+	ver      int
 	metrics  *ExporterMetrics
 	scrapers []Scraper
+
+	// 配置了interval的抓取器在后台运行，不在scrapers中.
+	scheduler *scheduler
+
+	details *DetailStore
+
+	// 按数据库缓存的连接池，连接串变化时重新创建.
+	conns *connManager
+
+	opts CollectorOptions
+}
+
+// 采集器的选项.
+type CollectorOptions struct {
+	// 数据库连接串.
+	DataSourceName string
+
+	// 覆盖连接串的host、port、user、dbname以及密码文件.
+	Connection ConnectionOptions
+
+	// 数据库连接的TLS选项.
+	TLS TLSOptions
+
+	// 同时运行的抓取器个数，同时也是连接池的最大连接数，小于1时按1处理.
+	Concurrency int
+
+	// 标签值和明细数据的脱敏规则.
+	Redaction RedactionOptions
+
+	// 所有逐个数据库查询的抓取器共用的数据库过滤列表.
+	Databases DatabaseFilter
+
+	// 连接的安全选项.
+	Safety SafetyOptions
+}
+
+func NewCollector(opts CollectorOptions, enabledScrapers []Scraper) *HdwCollector {
+	c := &HdwCollector{
+		metrics: NewMetrics(),
+		details: newDetailStore(),
+		conns:   newConnManager(opts),
+	}
+
+	c.setScrapers(opts, enabledScrapers)
+
+	return c
+}
+
+/**
+* 函数：Reload
+* 功能：替换采集器选项和启用的抓取器，连接串变化时替换已有连接，原来的连接在后台抓取器结束后关闭，下次抓取时重新建立
+ */
+func (c *HdwCollector) Reload(opts CollectorOptions, enabledScrapers []Scraper) {
+	c.mu.Lock()
+
+	connChanged := opts.DataSourceName != c.opts.DataSourceName || opts.Connection != c.opts.Connection || opts.TLS != c.opts.TLS || opts.Safety != c.opts.Safety
+
+	var oldConns *connManager
+	if connChanged {
+		oldConns = c.conns
+		c.conns = newConnManager(opts)
+	}
+
+	if c.db != nil {
+		if connChanged {
+			c.db.retire()
+			c.db = nil
+		} else {
+			c.db.db.SetMaxIdleConns(opts.concurrency())
+			c.db.db.SetMaxOpenConns(opts.concurrency())
+		}
+	}
+
+	oldScheduler := c.scheduler
+	c.setScrapers(opts, enabledScrapers)
+
+	c.mu.Unlock()
+
+	// 原来的后台抓取器结束后才能关闭它们使用的按数据库缓存的连接池
+	oldScheduler.wait()
+
+	if oldConns != nil {
+		oldConns.close()
+	}
+}
+
+/**
+* 函数：Close
+* 功能：停止后台抓取并关闭数据库连接，用于移除不再使用的采集器
+ */
+func (c *HdwCollector) Close() {
+	c.mu.Lock()
+
+	c.scheduler.stop()
+
+	if c.db != nil {
+		c.db.retire()
+		c.db = nil
+	}
+
+	c.mu.Unlock()
+
+	c.scheduler.wait()
+	c.conns.close()
+}
+
+/**
+* 函数：Details
+* 功能：返回采集器的明细数据缓存
+ */
+func (c *HdwCollector) Details() *DetailStore {
+	return c.details
 }
 
+func (c *HdwCollector) setScrapers(opts CollectorOptions, enabledScrapers []Scraper) {
+	redactor, err := newRedactor(opts.Redaction)
+	if err != nil {
+		logger.Errorf("invalid redaction rules, only builtin rules are used, error:%v", err)
+		redactor, _ = newRedactor(RedactionOptions{})
+	}
+
+	env := &scraperEnv{
+		conns:          c.conns,
+		databaseFilter: opts.Databases,
+		details:        c.details,
+		seriesDropped:  c.metrics.seriesDropped,
+		redactor:       redactor,
+		redactions:     c.metrics.redactions,
+	}
+
+	syncScrapers := make([]Scraper, 0, len(enabledScrapers))
+	backgroundScrapers := make([]Scraper, 0)
+
+	for _, scraper := range enabledScrapers {
+		if bh, ok := scraper.(baseHolder); ok {
+			bh.setEnv(env)
+		}
+
+		if scraperOptions(scraper).Interval > 0 {
+			backgroundScrapers = append(backgroundScrapers, scraper)
+		} else {
+			syncScrapers = append(syncScrapers, scraper)
+		}
+	}
+
+	if c.scheduler != nil {
+		c.scheduler.stop()
+	}
+
+	// 移除已停用抓取器的耗时和状态，错误计数保留
+	c.metrics.scraperDuration.Reset()
+	c.metrics.scraperSuccess.Reset()
+
+	c.opts = opts
+	c.scrapers = syncScrapers
+	c.scheduler = newScheduler(c, backgroundScrapers)
+}
 
-func NewCollector(enabledScrapers []Scraper) *HdwCollector {
-	return &HdwCollector{
-		metrics:  NewMetrics(),
-		scrapers: enabledScrapers,
+func (o CollectorOptions) concurrency() int {
+	if o.Concurrency < 1 {
+		return 1
 	}
+
+	return o.Concurrency
 }
 
 func (c *HdwCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collect(context.Background(), ch)
+}
+
+/**
+* 函数：WithContext
+* 功能：返回使用指定context抓取的Collector，用于将HTTP请求的取消和超时传递给抓取器
+ */
+func (c *HdwCollector) WithContext(ctx context.Context) prometheus.Collector {
+	return &contextCollector{collector: c, ctx: ctx}
+}
+
+type contextCollector struct {
+	collector *HdwCollector
+	ctx       context.Context
+}
+
+func (cc *contextCollector) Describe(ch chan<- *prometheus.Desc) {
+	cc.collector.Describe(ch)
+}
+
+func (cc *contextCollector) Collect(ch chan<- prometheus.Metric) {
+	cc.collector.collect(cc.ctx, ch)
+}
+
+func (c *HdwCollector) collect(ctx context.Context, ch chan<- prometheus.Metric) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.scrape(ch)
+	c.scrape(ctx, ch)
+	c.scheduler.collect(ch)
 
 	ch <- c.metrics.totalScraped
 	ch <- c.metrics.totalError
 	ch <- c.metrics.scrapeDuration
 	ch <- c.metrics.hdwUp
+	c.metrics.scraperDuration.Collect(ch)
+	c.metrics.scraperSuccess.Collect(ch)
+	c.metrics.scraperErrors.Collect(ch)
+	c.metrics.seriesDropped.Collect(ch)
+	c.metrics.redactions.Collect(ch)
+	c.opts.TLS.collectCertificates(ch)
 }
 
-
 func (c *HdwCollector) Describe(ch chan<- *prometheus.Desc) {
-	ch <- c.metrics.hdwUp.Desc()
-	ch <- c.metrics.scrapeDuration.Desc()
-	ch <- c.metrics.totalScraped.Desc()
-	ch <- c.metrics.totalError.Desc()
+	c.mu.Lock()
+	scrapers := append([]Scraper{}, c.scrapers...)
+	for _, ss := range c.scheduler.scrapers {
+		scrapers = append(scrapers, ss.scraper)
+	}
+	c.mu.Unlock()
+
+	c.metrics.describe(ch)
+	describeScrapers(ch, scrapers)
 }
 
-func (c *HdwCollector) scrape(ch chan<- prometheus.Metric) {
+func (c *HdwCollector) scrape(ctx context.Context, ch chan<- prometheus.Metric) {
 	start := time.Now()
 	watch := stopwatch.New("scrape")
 
-
 	c.metrics.totalScraped.Inc()
 	watch.MustStart("check connections")
-	err := c.checkHdwConn()
+	err := c.checkHdwConn(ctx)
 	watch.MustStop()
 	if err != nil {
 		c.metrics.totalError.Inc()
@@ -68,84 +259,163 @@ func (c *HdwCollector) scrape(ch chan<- prometheus.Metric) {
 
 		logger.Errorf("check database connection failed, error:%v", err)
 
+		// 连接失败时所有抓取器都没有运行，不能继续输出上一次的状态
+		for _, scraper := range c.scrapers {
+			c.metrics.scraperSuccess.WithLabelValues(scraper.Name()).Set(0)
+			c.metrics.scraperDuration.WithLabelValues(scraper.Name()).Set(0)
+		}
+
 		return
 	}
 
-	defer c.db.Close()
-
 	logger.Info("check connections ok!")
 	c.metrics.hdwUp.Set(1)
 
+	scrapers := make(chan Scraper)
+
+	var wg sync.WaitGroup
+	for i := 0; i < c.opts.concurrency(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for scraper := range scrapers {
+				elapsed, _ := c.runScraper(ctx, scraper, c.db.db, c.ver, ch)
+				watch.Record("scraping: "+scraper.Name(), elapsed)
+			}
+		}()
+	}
 
 	for _, scraper := range c.scrapers {
-		logger.Info("#### scraping start : " + scraper.Name())
-		watch.MustStart("scraping: " + scraper.Name())
-		err := scraper.Scrape(c.db, ch, c.ver)
-		watch.MustStop()
-		if err != nil {
-			logger.Errorf("get metrics for scraper:%s failed, error:%v", scraper.Name(), err.Error())
-		}
-		logger.Info("#### scraping end : " + scraper.Name())
+		scrapers <- scraper
+	}
+
+	close(scrapers)
+	wg.Wait()
+
+	elapsed := time.Since(start)
+	c.metrics.scrapeDuration.Set(elapsed.Seconds())
+
+	logger.Info(fmt.Sprintf("prometheus scraped hdw exporter successfully at %v, overall elapsed:%dms with concurrency %d, detail elapsed:%s",
+		time.Now(), elapsed.Milliseconds(), c.opts.concurrency(), watch.PrettyPrint()))
+}
+
+/**
+* 函数：runScraper
+* 功能：运行单个抓取器并返回其耗时，同时记录抓取器的耗时、是否成功以及错误分类，可以被多个goroutine同时调用
+*      抓取器配置了timeout时，超时后其正在执行的查询会被取消
+ */
+func (c *HdwCollector) runScraper(ctx context.Context, scraper Scraper, db *sql.DB, ver int, ch chan<- prometheus.Metric) (time.Duration, error) {
+	if ctx.Err() != nil {
+		logger.Errorf("skip scraper:%s, error:%v", scraper.Name(), ctx.Err())
+		c.metrics.scraperSuccess.WithLabelValues(scraper.Name()).Set(0)
+		c.metrics.scraperErrors.WithLabelValues(scraper.Name(), classifyError(ctx.Err())).Inc()
+		return 0, ctx.Err()
+	}
+
+	if timeout := scraperOptions(scraper).Timeout; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
 
-	c.metrics.scrapeDuration.Set(time.Since(start).Seconds())
+	logger.Info("#### scraping start : " + scraper.Name())
+	start := time.Now()
+	err := scraper.Scrape(ctx, db, ch, ver)
+	elapsed := time.Since(start)
+
+	c.metrics.scraperDuration.WithLabelValues(scraper.Name()).Set(elapsed.Seconds())
+	if err != nil {
+		logger.Errorf("get metrics for scraper:%s failed, error:%v", scraper.Name(), err.Error())
+		c.metrics.scraperSuccess.WithLabelValues(scraper.Name()).Set(0)
+		c.metrics.scraperErrors.WithLabelValues(scraper.Name(), classifyError(err)).Inc()
+	} else {
+		c.metrics.scraperSuccess.WithLabelValues(scraper.Name()).Set(1)
+	}
+	logger.Info("#### scraping end : " + scraper.Name())
 
-	logger.Info(fmt.Sprintf("prometheus scraped hdw exporter successfully at %v, detail elapsed:%s", time.Now(), watch.PrettyPrint()))
+	return elapsed, err
 }
 
+/**
+* 函数：connection
+* 功能：供后台抓取使用，检查并返回当前的数据库连接和版本，使用完后需要调用release
+ */
+func (c *HdwCollector) connection(ctx context.Context) (*sharedDB, int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-func (c *HdwCollector) checkHdwConn() (err error) {
+	if err := c.checkHdwConn(ctx); err != nil {
+		return nil, 0, err
+	}
+
+	c.db.acquire()
+
+	return c.db, c.ver, nil
+}
+
+func (c *HdwCollector) checkHdwConn(ctx context.Context) (err error) {
 	if c.db == nil {
-		return c.getHdwConnection()
+		return c.getHdwConnection(ctx)
 	}
 
-	if err = c.getHdwMajorVersion(c.db); err == nil {
+	if err = c.getHdwMajorVersion(ctx, c.db.db); err == nil {
 		return nil
-	} else {
-		_ = c.db.Close()
-		c.db = nil
-		return c.getHdwConnection()
 	}
+
+	// 请求超时或被取消时连接池本身没有问题，不重新建立连接
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	c.db.retire()
+	c.db = nil
+
+	return c.getHdwConnection(ctx)
 }
 
+func (c *HdwCollector) getHdwConnection(ctx context.Context) error {
 
-func (c *HdwCollector) getHdwConnection() error {
+	params, err := c.opts.connectionParams()
+	if err != nil {
+		return err
+	}
 
-	dataSourceName := os.Getenv("GPDB_DATA_SOURCE_URL")
+	logger.Infof("connecting to %s", maskedDataSourceName(params))
 
-	db, err := sql.Open("postgres", dataSourceName)
+	db, err := openDB(params, c.opts)
 
 	if err != nil {
 		return err
 	}
 
-	if err = c.getHdwMajorVersion(db); err != nil {
+	if err = c.getHdwMajorVersion(ctx, db); err != nil {
 		_ = db.Close()
 		return err
 	}
 
-	db.SetMaxIdleConns(1)
-	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(c.opts.concurrency())
+	db.SetMaxOpenConns(c.opts.concurrency())
 
-	c.db = db
+	c.db = &sharedDB{db: db}
 
 	return nil
 }
 
-
-func (c *HdwCollector) getHdwMajorVersion(db *sql.DB) error {
-	err := db.Ping()
+func (c *HdwCollector) getHdwMajorVersion(ctx context.Context, db *sql.DB) error {
+	err := db.PingContext(ctx)
 
 	if err != nil {
 		return err
 	}
 
-	rows, err := db.Query(verMajorSql)
+	rows, err := db.QueryContext(ctx, verMajorSql)
 
 	if err != nil {
 		return err
 	}
 
+	defer rows.Close()
+
 	for rows.Next() {
 		var verMajor int
 		errC := rows.Scan(&verMajor)
@@ -153,10 +423,52 @@ func (c *HdwCollector) getHdwMajorVersion(db *sql.DB) error {
 			return errC
 		}
 
-		c.ver=verMajor
+		c.ver = verMajor
 	}
 
-	defer rows.Close()
+	return rows.Err()
+}
 
-	return nil
+// 采集器共用的连接池，后台抓取器运行期间持有引用，替换后在所有引用释放时才关闭.
+type sharedDB struct {
+	db *sql.DB
+
+	mu      sync.Mutex
+	refs    int
+	retired bool
+}
+
+func (p *sharedDB) acquire() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.refs++
+}
+
+/**
+* 函数：release
+* 功能：释放一个引用，连接池已被替换且没有其他引用时关闭
+ */
+func (p *sharedDB) release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.refs--
+	if p.refs == 0 && p.retired {
+		_ = p.db.Close()
+	}
+}
+
+/**
+* 函数：retire
+* 功能：标记连接池不再使用，没有引用时立即关闭，否则在最后一个引用释放时关闭
+ */
+func (p *sharedDB) retire() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.retired = true
+	if p.refs == 0 {
+		_ = p.db.Close()
+	}
 }
@@ -0,0 +1,282 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/lib/pq"
+	logger "github.com/prometheus/common/log"
+)
+
+/**
+ * 按数据库缓存的连接池：bloat、skew等需要逐个数据库查询的抓取器通过它获取指定数据库的连接，
+ * 不再在每次抓取时为每个数据库新建并关闭连接，已删除的数据库的连接池会被释放
+ */
+
+const (
+	getDBNameSql = `select datname, datallowconn from pg_database where datname not in ('template0','template1');`
+
+	// 每个数据库的连接池最大连接数以及空闲连接数.
+	perDatabaseMaxOpenConns = 2
+	perDatabaseMaxIdleConns = 1
+
+	// 连接的最长使用时间，避免长时间不抓取的数据库一直占用连接.
+	perDatabaseConnMaxLifetime = 10 * time.Minute
+)
+
+// 连接池管理器已关闭，采集器已被移除或连接参数已变化.
+var errConnManagerClosed = errors.New("connection manager is closed")
+
+// 按数据库缓存连接池，可以被多个抓取器同时使用.
+type connManager struct {
+	mu sync.Mutex
+
+	// 解析后的连接参数，dbname由每个连接池替换.
+	params map[string]string
+	opts   CollectorOptions
+	err    error
+
+	pools  map[string]*sql.DB
+	closed bool
+}
+
+/**
+* 函数：newConnManager
+* 功能：解析采集器的连接串并创建连接池管理器，连接串不合法时在获取连接时返回错误
+ */
+func newConnManager(opts CollectorOptions) *connManager {
+	m := &connManager{pools: make(map[string]*sql.DB), opts: opts}
+
+	m.params, m.err = opts.connectionParams()
+
+	return m
+}
+
+/**
+* 函数：database
+* 功能：返回指定数据库的连接池，不存在时创建，管理器已关闭时返回错误
+ */
+func (m *connManager) database(dbname string) (*sql.DB, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return nil, errConnManagerClosed
+	}
+
+	if pool, ok := m.pools[dbname]; ok {
+		return pool, nil
+	}
+
+	params := make(map[string]string, len(m.params)+1)
+	for k, v := range m.params {
+		params[k] = v
+	}
+	params["dbname"] = dbname
+
+	pool, err := openDB(params, m.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	pool.SetMaxOpenConns(perDatabaseMaxOpenConns)
+	pool.SetMaxIdleConns(perDatabaseMaxIdleConns)
+	pool.SetConnMaxLifetime(perDatabaseConnMaxLifetime)
+
+	m.pools[dbname] = pool
+
+	return pool, nil
+}
+
+/**
+* 函数：evict
+* 功能：关闭不在existing中的数据库的连接池，existing为当前存在的所有数据库
+ */
+func (m *connManager) evict(existing []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make(map[string]bool, len(existing))
+	for _, name := range existing {
+		names[name] = true
+	}
+
+	for name, pool := range m.pools {
+		if !names[name] {
+			logger.Infof("database %s no longer exists, close its connection pool", name)
+			_ = pool.Close()
+			delete(m.pools, name)
+		}
+	}
+}
+
+/**
+* 函数：close
+* 功能：关闭所有连接池，之后不再创建新的连接池
+ */
+func (m *connManager) close() {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+
+	m.evict(nil)
+}
+
+/**
+* 函数：databases
+* 功能：查询除模板库外需要抓取的数据库，数据库需要同时满足全局和抓取器的过滤条件，同时释放已删除数据库的连接池
+ */
+func (e *scraperEnv) databases(ctx context.Context, db *sql.DB, filter DatabaseFilter) ([]string, error) {
+	rows, err := db.QueryContext(ctx, getDBNameSql)
+	logger.Infof("Query Database: %s", getDBNameSql)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	existing := make([]string, 0)
+	names := make([]string, 0)
+
+	for rows.Next() {
+		var dbname string
+		var allowConn bool
+		if err = rows.Scan(&dbname, &allowConn); err != nil {
+			return nil, err
+		}
+
+		existing = append(existing, dbname)
+
+		if e.databaseFilter.allowed(dbname, allowConn) && filter.allowed(dbname, allowConn) {
+			names = append(names, dbname)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	e.conns.evict(existing)
+
+	return names, nil
+}
+
+/**
+* 函数：parseDataSourceName
+* 功能：解析URL（postgres://...）或key=value形式的连接串，返回连接参数
+ */
+func parseDataSourceName(dataSourceName string) (map[string]string, error) {
+	dsn := strings.TrimSpace(dataSourceName)
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		converted, err := pq.ParseURL(dsn)
+		if err != nil {
+			// url.Parse的错误信息中包含完整的连接串，只保留错误原因避免泄露密码
+			if urlErr, ok := err.(*url.Error); ok {
+				err = urlErr.Err
+			}
+
+			return nil, fmt.Errorf("parse data source name failed: %v", err)
+		}
+
+		dsn = converted
+	}
+
+	params := make(map[string]string)
+
+	for i := 0; i < len(dsn); {
+		for i < len(dsn) && unicode.IsSpace(rune(dsn[i])) {
+			i++
+		}
+
+		if i >= len(dsn) {
+			break
+		}
+
+		eq := strings.IndexByte(dsn[i:], '=')
+		if eq < 0 {
+			return nil, fmt.Errorf("parse data source name failed: missing \"=\" at offset %d", i)
+		}
+
+		key := strings.TrimSpace(dsn[i : i+eq])
+		i += eq + 1
+
+		for i < len(dsn) && unicode.IsSpace(rune(dsn[i])) {
+			i++
+		}
+
+		var value strings.Builder
+		if i < len(dsn) && dsn[i] == '\'' {
+			i++
+			closed := false
+			for ; i < len(dsn); i++ {
+				if dsn[i] == '\\' && i+1 < len(dsn) {
+					i++
+					value.WriteByte(dsn[i])
+					continue
+				}
+
+				if dsn[i] == '\'' {
+					closed = true
+					i++
+					break
+				}
+
+				value.WriteByte(dsn[i])
+			}
+
+			if !closed {
+				return nil, fmt.Errorf("parse data source name failed: unterminated quoted value of %q", key)
+			}
+		} else {
+			for ; i < len(dsn) && !unicode.IsSpace(rune(dsn[i])); i++ {
+				if dsn[i] == '\\' && i+1 < len(dsn) {
+					i++
+				}
+
+				value.WriteByte(dsn[i])
+			}
+		}
+
+		if key == "" {
+			return nil, fmt.Errorf("parse data source name failed: empty key")
+		}
+
+		params[key] = value.String()
+	}
+
+	return params, nil
+}
+
+/**
+* 函数：formatDataSourceName
+* 功能：将连接参数转换为key=value形式的连接串，参数按名称排序
+ */
+func formatDataSourceName(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		v := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(params[k])
+		pairs = append(pairs, fmt.Sprintf("%s='%s'", k, v))
+	}
+
+	return strings.Join(pairs, " ")
+}
@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 
@@ -30,31 +31,31 @@ const (
 )
 
 var (
-	currentConnDesc = prometheus.NewDesc(
+	currentConnDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemCluster, "total_connections"),
 		"Current connections of hashdata cluster at scrape time",
 		nil, nil,
 	)
 
-	idleConnDesc = prometheus.NewDesc(
+	idleConnDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemCluster, "idle_connections"),
 		"Idle connections of hashdata cluster at scape time",
 		nil, nil,
 	)
 
-	activeConnDesc = prometheus.NewDesc(
+	activeConnDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemCluster, "active_connections"),
 		"Active connections of hashdata cluster at scape time",
 		nil, nil,
 	)
 
-	runningConnDesc = prometheus.NewDesc(
+	runningConnDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemCluster, "running_connections"),
 		"Running sql count of hashdata cluster at scape time",
 		nil, nil,
 	)
 
-	queuingConnDesc = prometheus.NewDesc(
+	queuingConnDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemCluster, "waiting_connections"),
 		"Waiting sql count of hashdata cluster at scape time",
 		nil, nil,
@@ -65,19 +66,21 @@ func NewConnectionsScraper() Scraper {
 	return &connectionsScraper{}
 }
 
-type connectionsScraper struct{}
+type connectionsScraper struct {
+	baseScraper
+}
 
 func (connectionsScraper) Name() string {
 	return "connections_scraper"
 }
 
-func (connectionsScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+func (connectionsScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
 	querySql := connectionsSql_V6
 	if ver > 3 && ver < 6 {
 		querySql = connectionsSql_V5
 	}
 
-	rows, err := db.Query(querySql)
+	rows, err := db.QueryContext(ctx, querySql)
 	logger.Infof("Query Database: %s", querySql)
 
 	if err != nil {
@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"database/sql"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -35,49 +36,49 @@ const (
 )
 
 var (
-	totalPerUserDesc = prometheus.NewDesc(
+	totalPerUserDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemCluster, "total_connections_per_user"),
 		"Total connections of specified database user",
 		[]string{"usename"}, nil,
 	)
 
-	activePerUserDesc = prometheus.NewDesc(
+	activePerUserDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemCluster, "active_connections_per_user"),
 		"Active connections of specified database user",
 		[]string{"usename"}, nil,
 	)
 
-	idlePerUserDesc = prometheus.NewDesc(
+	idlePerUserDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemCluster, "idle_connections_per_user"),
 		"Idle connections of specified database user",
 		[]string{"usename"}, nil,
 	)
 
-	totalPerClientDesc = prometheus.NewDesc(
+	totalPerClientDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemCluster, "total_connections_per_client"),
 		"Total connections of specified database user",
 		[]string{"client"}, nil,
 	)
 
-	activePerClientDesc = prometheus.NewDesc(
+	activePerClientDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemCluster, "active_connections_per_client"),
 		"Active connections of specified database user",
 		[]string{"client"}, nil,
 	)
 
-	idlePerClientDesc = prometheus.NewDesc(
+	idlePerClientDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemCluster, "idle_connections_per_client"),
 		"Idle connections of specified database user",
 		[]string{"client"}, nil,
 	)
 
-	totalCountClientDesc = prometheus.NewDesc(
+	totalCountClientDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemCluster, "total_client_count"),
 		"The total client count of hashdata database",
 		nil, nil,
 	)
 
-	totalCountOnlineUsersDesc = prometheus.NewDesc(
+	totalCountOnlineUsersDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemCluster, "total_online_user_count"),
 		"The total online user count of hashdata database",
 		nil, nil,
@@ -85,29 +86,31 @@ var (
 )
 
 func NewConnDetailScraper() Scraper {
-	return connectionsDetailScraper{}
+	return &connectionsDetailScraper{}
 }
 
-type connectionsDetailScraper struct{}
+type connectionsDetailScraper struct {
+	baseScraper
+}
 
 func (connectionsDetailScraper) Name() string {
 	return "connections_detail_scraper"
 }
 
-func (connectionsDetailScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
-	errU := scrapeLoadByUser(db, ch, ver)
-	errC := scrapeLoadByClient(db, ch, ver)
+func (connectionsDetailScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+	errU := scrapeLoadByUser(ctx, db, ch, ver)
+	errC := scrapeLoadByClient(ctx, db, ch, ver)
 
 	return combineErr(errC, errU)
 }
 
-func scrapeLoadByUser(db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+func scrapeLoadByUser(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
 	querySql := connectionsByUserSql_V6
 	if ver > 3 && ver < 6 {
 		querySql = connectionsByUserSql_V5
 	}
 
-	rows, err := db.Query(querySql)
+	rows, err := db.QueryContext(ctx, querySql)
 
 	logger.Infof("Query Database: %s", querySql)
 
@@ -143,13 +146,13 @@ func scrapeLoadByUser(db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
 	return combineErr(errs...)
 }
 
-func scrapeLoadByClient(db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+func scrapeLoadByClient(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
 	querySql := connectionsByClientAddressSql_V6
 	if ver > 3 && ver < 6 {
 		querySql = connectionsByClientAddressSql_V5
 	}
 
-	rows, err := db.Query(querySql)
+	rows, err := db.QueryContext(ctx, querySql)
 
 	if err != nil {
 		return err
@@ -0,0 +1,206 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+/**
+ * 连接凭据：连接串之外可以单独指定host、port、user、dbname以及密码文件，
+ * 密码文件在每次建立新连接时重新读取，Kubernetes Secret等方式轮换密码后无需重启，
+ * 连接串中没有密码且未指定密码文件时由驱动读取.pgpass或PGPASSFILE
+ */
+
+// 日志中替换密码使用的字符串.
+const maskedPassword = "***"
+
+// 日志中需要替换的连接参数：密码、客户端私钥的密码以及私钥文件.
+var maskedParams = []string{"password", "sslpassword", "sslkey"}
+
+// 覆盖连接串中对应参数的连接选项，为空的字段不覆盖.
+type ConnectionOptions struct {
+	Host     string
+	Port     string
+	User     string
+	Database string
+
+	// 密码文件，文件内容去掉末尾换行后作为密码，优先于连接串中的密码.
+	PasswordFile string
+}
+
+/**
+* 函数：Validate
+* 功能：校验密码文件是否可以读取
+ */
+func (o ConnectionOptions) Validate() error {
+	if o.PasswordFile == "" {
+		return nil
+	}
+
+	_, err := readPasswordFile(o.PasswordFile)
+
+	return err
+}
+
+/**
+* 函数：connectionParams
+* 功能：返回实际使用的连接参数，依次应用连接串、单独指定的连接选项、TLS选项以及安全选项
+ */
+func (o CollectorOptions) connectionParams() (map[string]string, error) {
+	params, err := parseDataSourceName(o.DataSourceName)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := map[string]string{
+		"host":   o.Connection.Host,
+		"port":   o.Connection.Port,
+		"user":   o.Connection.User,
+		"dbname": o.Connection.Database,
+	}
+
+	for k, v := range overrides {
+		if v != "" {
+			params[k] = v
+		}
+	}
+
+	o.TLS.apply(params)
+	o.Safety.apply(params)
+
+	return params, nil
+}
+
+/**
+* 函数：openDB
+* 功能：按连接参数创建连接池，指定了密码文件时每次建立连接前重新读取密码，指定了server_name时用其校验服务器证书
+ */
+func openDB(params map[string]string, opts CollectorOptions) (*sql.DB, error) {
+	if opts.Connection.PasswordFile == "" && opts.TLS.ServerName == "" {
+		return sql.Open("postgres", formatDataSourceName(params))
+	}
+
+	return sql.OpenDB(&connector{
+		params:       params,
+		passwordFile: opts.Connection.PasswordFile,
+		serverName:   opts.TLS.ServerName,
+	}), nil
+}
+
+// 每次建立连接时从文件读取密码、按server_name校验服务器证书的driver.Connector.
+type connector struct {
+	params       map[string]string
+	passwordFile string
+	serverName   string
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	params := make(map[string]string, len(c.params)+1)
+	for k, v := range c.params {
+		params[k] = v
+	}
+
+	if c.passwordFile != "" {
+		password, err := readPasswordFile(c.passwordFile)
+		if err != nil {
+			return nil, err
+		}
+
+		params["password"] = password
+	}
+
+	if c.serverName == "" {
+		pqConnector, err := pq.NewConnector(formatDataSourceName(params))
+		if err != nil {
+			return nil, err
+		}
+
+		return pqConnector.Connect(ctx)
+	}
+
+	// 驱动使用host校验verify-full的服务器证书，因此将host替换为server_name，实际连接原来的host
+	dialer := &hostDialer{host: params["host"]}
+	params["host"] = c.serverName
+
+	return pq.DialOpen(dialer, formatDataSourceName(params))
+}
+
+func (c *connector) Driver() driver.Driver {
+	return &pq.Driver{}
+}
+
+// 忽略驱动传入的主机名，连接到指定host的pq.Dialer.
+type hostDialer struct {
+	host   string
+	dialer net.Dialer
+}
+
+func (d *hostDialer) Dial(network, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+func (d *hostDialer) DialTimeout(network, address string, timeout time.Duration) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return d.DialContext(ctx, network, address)
+}
+
+func (d *hostDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	host := d.host
+	if host == "" {
+		host = "localhost"
+	}
+
+	return d.dialer.DialContext(ctx, network, net.JoinHostPort(host, port))
+}
+
+/**
+* 函数：readPasswordFile
+* 功能：读取密码文件，去掉末尾的换行符
+ */
+func readPasswordFile(filename string) (string, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", fmt.Errorf("read password file failed: %v", err)
+	}
+
+	password := strings.TrimRight(string(content), "\r\n")
+	if password == "" {
+		return "", fmt.Errorf("password file %s is empty", filename)
+	}
+
+	return password, nil
+}
+
+/**
+* 函数：maskedDataSourceName
+* 功能：返回密码、私钥等参数被替换后的连接串，用于日志输出，URL形式中的密码在解析后位于password参数中
+ */
+func maskedDataSourceName(params map[string]string) string {
+	masked := make(map[string]string, len(params))
+	for k, v := range params {
+		masked[k] = v
+	}
+
+	for _, k := range maskedParams {
+		if _, ok := masked[k]; ok {
+			masked[k] = maskedPassword
+		}
+	}
+
+	return formatDataSourceName(masked)
+}
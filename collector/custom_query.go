@@ -0,0 +1,335 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	logger "github.com/prometheus/common/log"
+	"github.com/prometheus/common/model"
+)
+
+/**
+ * 自定义查询抓取器：由查询文件中的每个查询生成一个抓取器，无需为新的指标编写抓取器
+ * 查询结果中labels列作为标签，values列作为指标值，指标名称为hashdata_custom_<name>_<column>
+ * 每个查询作为独立的抓取器运行，耗时和错误通过hashdata_exporter_scraper_*{scraper="custom_query:<name>"}输出
+ */
+
+// 自定义查询抓取器名称的前缀.
+const customQueryPrefix = "custom_query:"
+
+// 查询文件的顶层结构.
+type CustomQueriesFile struct {
+	Queries []CustomQuery `yaml:"queries"`
+}
+
+// 一个自定义查询.
+type CustomQuery struct {
+	// 查询名称，同时作为指标名称的一部分.
+	Name string `yaml:"name"`
+
+	Help string `yaml:"help,omitempty"`
+	SQL  string `yaml:"sql"`
+
+	// 执行查询的数据库，为空时使用连接串中的数据库；配置后指标会带上datname标签.
+	Databases []string `yaml:"databases,omitempty"`
+
+	// 适用的HashData主版本范围，为0时不限制.
+	MinVersion int `yaml:"min_version,omitempty"`
+	MaxVersion int `yaml:"max_version,omitempty"`
+
+	// 作为标签的列.
+	Labels []string `yaml:"labels,omitempty"`
+
+	// 作为指标值的列.
+	Values []CustomValue `yaml:"values"`
+
+	// 查询的超时时间和后台运行间隔，含义与抓取器的timeout、interval相同.
+	Timeout  time.Duration `yaml:"timeout,omitempty"`
+	Interval time.Duration `yaml:"interval,omitempty"`
+}
+
+// 作为指标值的列.
+type CustomValue struct {
+	Column string `yaml:"column"`
+
+	// gauge或counter，默认为gauge.
+	Type string `yaml:"type,omitempty"`
+
+	// 为空时使用查询的help.
+	Help string `yaml:"help,omitempty"`
+}
+
+type customValueDesc struct {
+	column    string
+	valueType prometheus.ValueType
+	desc      *prometheus.Desc
+}
+
+type customQueryScraper struct {
+	baseScraper
+
+	query  CustomQuery
+	values []customValueDesc
+}
+
+/**
+* 函数：NewCustomQueryScrapers
+* 功能：校验查询并为每个查询创建抓取器，查询名称不能重复
+ */
+func NewCustomQueryScrapers(queries []CustomQuery) ([]Scraper, error) {
+	scrapers := make([]Scraper, 0, len(queries))
+	names := make(map[string]bool, len(queries))
+
+	// 指标名称由查询名称和列名拼接而成，不同的查询也可能得到相同的名称
+	metrics := make(map[string]string)
+
+	for _, q := range queries {
+		if names[q.Name] {
+			return nil, fmt.Errorf("custom query %q is defined more than once", q.Name)
+		}
+
+		names[q.Name] = true
+
+		scraper, err := newCustomQueryScraper(q)
+		if err != nil {
+			return nil, fmt.Errorf("custom query %q: %v", q.Name, err)
+		}
+
+		for _, v := range scraper.values {
+			metric := customMetricName(q.Name, v.column)
+			if other, ok := metrics[metric]; ok {
+				return nil, fmt.Errorf("custom query %q: metric %s is also defined by custom query %q", q.Name, metric, other)
+			}
+
+			metrics[metric] = q.Name
+		}
+
+		scrapers = append(scrapers, scraper)
+	}
+
+	return scrapers, nil
+}
+
+func newCustomQueryScraper(q CustomQuery) (*customQueryScraper, error) {
+	if q.Name == "" {
+		return nil, errors.New("name must not be empty")
+	}
+
+	if !model.IsValidMetricName(model.LabelValue(q.Name)) {
+		return nil, errors.New("name must be a valid metric name")
+	}
+
+	if strings.TrimSpace(q.SQL) == "" {
+		return nil, errors.New("sql must not be empty")
+	}
+
+	if q.MinVersion < 0 || q.MaxVersion < 0 || q.MaxVersion > 0 && q.MinVersion > q.MaxVersion {
+		return nil, errors.New("min_version and max_version must be a valid range")
+	}
+
+	if q.Timeout < 0 || q.Interval < 0 {
+		return nil, errors.New("timeout and interval must not be negative")
+	}
+
+	if len(q.Values) == 0 {
+		return nil, errors.New("at least one value column is required")
+	}
+
+	labels := append([]string{}, q.Labels...)
+	if len(q.Databases) > 0 {
+		labels = append(labels, "datname")
+	}
+
+	columns := make(map[string]bool)
+	for _, label := range labels {
+		if !model.LabelName(label).IsValid() || strings.HasPrefix(label, model.ReservedLabelPrefix) {
+			return nil, fmt.Errorf("invalid label column %q", label)
+		}
+
+		if columns[label] {
+			return nil, fmt.Errorf("column %q is used more than once", label)
+		}
+
+		columns[label] = true
+	}
+
+	s := &customQueryScraper{query: q}
+	s.opts = ScraperOptions{Timeout: q.Timeout, Interval: q.Interval}
+
+	for _, v := range q.Values {
+		if !model.IsValidMetricName(model.LabelValue(q.Name + "_" + v.Column)) {
+			return nil, fmt.Errorf("invalid value column %q", v.Column)
+		}
+
+		if columns[v.Column] {
+			return nil, fmt.Errorf("column %q is used more than once", v.Column)
+		}
+
+		columns[v.Column] = true
+
+		valueType := prometheus.GaugeValue
+		switch v.Type {
+		case "", "gauge":
+		case "counter":
+			valueType = prometheus.CounterValue
+		default:
+			return nil, fmt.Errorf("value column %q: unknown type %q, must be gauge or counter", v.Column, v.Type)
+		}
+
+		help := v.Help
+		if help == "" {
+			help = q.Help
+		}
+
+		if help == "" {
+			help = fmt.Sprintf("Column %s of custom query %s", v.Column, q.Name)
+		}
+
+		s.values = append(s.values, customValueDesc{
+			column:    v.Column,
+			valueType: valueType,
+			desc:      prometheus.NewDesc(customMetricName(q.Name, v.Column), help, labels, nil),
+		})
+	}
+
+	return s, nil
+}
+
+func (s *customQueryScraper) Name() string {
+	return customQueryPrefix + s.query.Name
+}
+
+// 自定义查询的值列输出的指标名称.
+func customMetricName(query, column string) string {
+	return prometheus.BuildFQName(namespace, "custom", query+"_"+column)
+}
+
+func (s *customQueryScraper) Describe(ch chan<- *prometheus.Desc) {
+	for _, v := range s.values {
+		ch <- v.desc
+	}
+}
+
+func (s *customQueryScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+	if s.query.MinVersion > 0 && ver < s.query.MinVersion || s.query.MaxVersion > 0 && ver > s.query.MaxVersion {
+		logger.Infof("skip custom query %s for version %d", s.query.Name, ver)
+		return nil
+	}
+
+	// 查询结果作为标签值，与*_detail指标一样脱敏并截断，不限制行数
+	guard := s.env.guard(s.Name(), s.opts)
+
+	if len(s.query.Databases) == 0 {
+		return s.queryDatabase(ctx, db, "", guard, ch)
+	}
+
+	errs := make([]error, 0)
+
+	for _, dbname := range s.query.Databases {
+		conn, err := s.env.conns.database(dbname)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if err = s.queryDatabase(ctx, conn, dbname, guard, ch); err != nil {
+			errs = append(errs, fmt.Errorf("database %s: %v", dbname, err))
+		}
+	}
+
+	return combineErr(errs...)
+}
+
+/**
+* 函数：queryDatabase
+* 功能：在一个数据库上执行查询并输出指标，dbname不为空时作为datname标签，标签值经过保护器脱敏和截断
+ */
+func (s *customQueryScraper) queryDatabase(ctx context.Context, db *sql.DB, dbname string, guard *seriesGuard, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx, s.query.SQL)
+	logger.Infof("Query Database: %s", s.query.SQL)
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	index := make(map[string]int, len(columns))
+	for i, column := range columns {
+		index[column] = i
+	}
+
+	for _, column := range s.query.Labels {
+		if _, ok := index[column]; !ok {
+			return fmt.Errorf("label column %q is not in the result columns %v", column, columns)
+		}
+	}
+
+	for _, v := range s.values {
+		if _, ok := index[v.column]; !ok {
+			return fmt.Errorf("value column %q is not in the result columns %v", v.column, columns)
+		}
+	}
+
+	for rows.Next() {
+		fields := make([]sql.NullString, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range fields {
+			dest[i] = &fields[i]
+		}
+
+		if err = rows.Scan(dest...); err != nil {
+			return err
+		}
+
+		labelValues := make([]string, 0, len(s.query.Labels)+1)
+		for _, column := range s.query.Labels {
+			labelValues = append(labelValues, fields[index[column]].String)
+		}
+
+		if dbname != "" {
+			labelValues = append(labelValues, dbname)
+		}
+
+		labelValues = guard.labels(labelValues...)
+
+		for _, v := range s.values {
+			field := fields[index[v.column]]
+			if !field.Valid {
+				continue
+			}
+
+			value, err := parseCustomValue(field.String)
+			if err != nil {
+				return fmt.Errorf("value column %q: %v", v.column, err)
+			}
+
+			ch <- prometheus.MustNewConstMetric(v.desc, v.valueType, value, labelValues...)
+		}
+	}
+
+	return rows.Err()
+}
+
+// 将列值转换为指标值，布尔值t/f转换为1/0.
+func parseCustomValue(value string) (float64, error) {
+	switch value {
+	case "t", "true":
+		return 1, nil
+	case "f", "false":
+		return 0, nil
+	}
+
+	return strconv.ParseFloat(value, 64)
+}
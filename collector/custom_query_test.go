@@ -0,0 +1,82 @@
+package collector
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewCustomQueryScrapers(t *testing.T) {
+	valid := func(name string) CustomQuery {
+		return CustomQuery{
+			Name:   name,
+			SQL:    "select usename, count(*) as sessions from pg_stat_activity group by usename",
+			Labels: []string{"usename"},
+			Values: []CustomValue{{Column: "sessions"}},
+		}
+	}
+
+	cases := []struct {
+		name    string
+		queries []CustomQuery
+		modify  func(q *CustomQuery)
+		err     string
+	}{
+		{name: "valid", queries: []CustomQuery{valid("sessions"), valid("sessions_by_user")}},
+		{name: "counter", modify: func(q *CustomQuery) { q.Values[0].Type = "counter" }},
+		{name: "databases", modify: func(q *CustomQuery) { q.Databases = []string{"postgres"} }},
+		{name: "empty name", modify: func(q *CustomQuery) { q.Name = "" }, err: "name must not be empty"},
+		{name: "invalid name", modify: func(q *CustomQuery) { q.Name = "user-sessions" }, err: "name must be a valid metric name"},
+		{name: "empty sql", modify: func(q *CustomQuery) { q.SQL = " " }, err: "sql must not be empty"},
+		{name: "version range", modify: func(q *CustomQuery) { q.MinVersion, q.MaxVersion = 7, 6 }, err: "min_version and max_version"},
+		{name: "negative interval", modify: func(q *CustomQuery) { q.Interval = -1 }, err: "must not be negative"},
+		{name: "missing value column", modify: func(q *CustomQuery) { q.Values = nil }, err: "at least one value column is required"},
+		{name: "invalid label", modify: func(q *CustomQuery) { q.Labels = []string{"user name"} }, err: `invalid label column "user name"`},
+		{name: "reserved label", modify: func(q *CustomQuery) { q.Labels = []string{"__usename"} }, err: `invalid label column "__usename"`},
+		{name: "duplicate label", modify: func(q *CustomQuery) { q.Labels = []string{"usename", "usename"} }, err: `column "usename" is used more than once`},
+		{
+			name:   "datname reserved for databases",
+			modify: func(q *CustomQuery) { q.Labels, q.Databases = []string{"datname"}, []string{"postgres"} },
+			err:    `column "datname" is used more than once`,
+		},
+		{name: "value used as label", modify: func(q *CustomQuery) { q.Labels = []string{"sessions"} }, err: `column "sessions" is used more than once`},
+		{name: "unknown type", modify: func(q *CustomQuery) { q.Values[0].Type = "histogram" }, err: `unknown type "histogram"`},
+		{name: "duplicate query name", queries: []CustomQuery{valid("sessions"), valid("sessions")}, err: `custom query "sessions" is defined more than once`},
+		{
+			name: "duplicate metric name",
+			queries: []CustomQuery{
+				{Name: "etl", SQL: "select 1 as job_lag", Values: []CustomValue{{Column: "job_lag"}}},
+				{Name: "etl_job", SQL: "select 1 as lag", Values: []CustomValue{{Column: "lag"}}},
+			},
+			err: `metric hashdata_custom_etl_job_lag is also defined by custom query "etl"`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			queries := c.queries
+			if queries == nil {
+				q := valid("sessions")
+				c.modify(&q)
+				queries = []CustomQuery{q}
+			}
+
+			scrapers, err := NewCustomQueryScrapers(queries)
+
+			if c.err != "" {
+				if err == nil || !strings.Contains(err.Error(), c.err) {
+					t.Fatalf("NewCustomQueryScrapers() error = %v, expected %q", err, c.err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("NewCustomQueryScrapers() failed: %v", err)
+			}
+
+			if len(scrapers) != len(queries) || scrapers[0].Name() != customQueryPrefix+queries[0].Name {
+				t.Errorf("unexpected scrapers %v", scrapers)
+			}
+		})
+	}
+}
@@ -4,8 +4,6 @@ import (
 	"container/list"
 	"context"
 	"database/sql"
-	"os"
-	"strings"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -56,42 +54,42 @@ const (
 )
 
 var (
-	databaseSizeDesc = prometheus.NewDesc(
+	databaseSizeDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "database_name_mb_size"), //指标的名称
 		"Total MB size of each database name in the file system",                  //帮助信息，显示在指标的上面作为注释
 		[]string{"dbname"}, //定义的label名称数组
 		nil,                //定义的Labels
 	)
 
-	tablesCountDesc = prometheus.NewDesc(
+	tablesCountDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "database_table_total_count"),
 		"Total table count of each database name in the file system",
 		[]string{"dbname"},
 		nil,
 	)
 
-	bloatTableDesc = prometheus.NewDesc(
+	bloatTableDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemServer, "database_table_bloat_list"),
 		"Bloat table list of each database name in hashdata cluster",
 		[]string{"dbname", "schema", "table", "relpages", "exppages"},
 		nil,
 	)
 
-	skewTableDesc = prometheus.NewDesc(
+	skewTableDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemServer, "database_table_skew_list"),
 		"Skew table list of each database name in hashdata cluster",
 		[]string{"dbname", "schema", "table", "size"},
 		nil,
 	)
 
-	hitCacheRateDesc = prometheus.NewDesc(
+	hitCacheRateDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemServer, "database_hit_cache_percent_rate"),
 		"Cache hit percent rat for all of database in hashdata server system",
 		nil,
 		nil,
 	)
 
-	txCommitRateDesc = prometheus.NewDesc(
+	txCommitRateDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemServer, "database_transition_commit_percent_rate"),
 		"Transition commit percent rat for all of database in hashdata server system",
 		nil,
@@ -100,21 +98,42 @@ var (
 )
 
 func NewDatabaseSizeScraper() Scraper {
-	return databaseSizeScraper{}
+	return &databaseSizeScraper{}
 }
 
-type databaseSizeScraper struct{}
+type databaseSizeScraper struct {
+	baseScraper
+}
 
 func (databaseSizeScraper) Name() string {
 	return "database_size_scraper"
 }
 
-func (databaseSizeScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
-	ctx := context.Background()
-	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+func (s *databaseSizeScraper) Configure(opts ScraperOptions) error {
+	if err := opts.check(supportedOptions{databases: true}); err != nil {
+		return err
+	}
+
+	s.opts = opts
+
+	return nil
+}
+
+func (s *databaseSizeScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+	ctx, cancel := context.WithTimeout(ctx, s.opts.timeout(time.Second*10))
 
 	defer cancel()
 
+	databases, err := s.env.databases(ctx, db, s.opts.Databases)
+	if err != nil {
+		return err
+	}
+
+	allowed := make(map[string]bool, len(databases))
+	for _, dbname := range databases {
+		allowed[dbname] = true
+	}
+
 	logger.Infof("Query Database: %s", databaseSizeSql)
 	rows, err := db.QueryContext(ctx, databaseSizeSql)
 	if err != nil {
@@ -137,13 +156,17 @@ func (databaseSizeScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver i
 			continue
 		}
 
+		if !allowed[dbname] {
+			continue
+		}
+
 		ch <- prometheus.MustNewConstMetric(databaseSizeDesc, prometheus.GaugeValue, mbSize, dbname)
 		names.PushBack(dbname)
 	}
 
 	for item := names.Front(); nil != item; item = item.Next() {
 		dbname := item.Value.(string)
-		count, err := queryTablesCount(dbname, ch)
+		count, err := queryTablesCount(ctx, s.env.conns, dbname, ch)
 		if err != nil {
 			errs = append(errs, err)
 			continue
@@ -152,12 +175,12 @@ func (databaseSizeScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver i
 		ch <- prometheus.MustNewConstMetric(tablesCountDesc, prometheus.GaugeValue, count, dbname)
 	}
 
-	//	errM := queryHitCacheRate(db, ch)
+	//	errM := queryHitCacheRate(ctx, db, ch)
 	//	if errM != nil {
 	//		errs = append(errs, errM)
 	//	}
 	//
-	//	errN := queryTxCommitRate(db, ch)
+	//	errN := queryTxCommitRate(ctx, db, ch)
 	//	if errN != nil {
 	//		errs = append(errs, errN)
 	//	}
@@ -165,20 +188,15 @@ func (databaseSizeScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver i
 	return combineErr(errs...)
 }
 
-func queryTablesCount(dbname string, ch chan<- prometheus.Metric) (count float64, err error) {
-	dataSourceName := os.Getenv("GPDB_DATA_SOURCE_URL")
-	newDataSourceName := strings.Replace(dataSourceName, "/postgres", "/"+dbname, 1)
-	logger.Infof("Connection string is : %s", newDataSourceName)
-	conn, errA := sql.Open("postgres", newDataSourceName)
+func queryTablesCount(ctx context.Context, conns *connManager, dbname string, ch chan<- prometheus.Metric) (count float64, err error) {
+	conn, errA := conns.database(dbname)
 
 	if errA != nil {
 		err = errA
 		return
 	}
 
-	defer conn.Close()
-
-	rows, errB := conn.Query(tableCountSql)
+	rows, errB := conn.QueryContext(ctx, tableCountSql)
 	logger.Infof("Query Database: %s", tableCountSql)
 
 	if errB != nil {
@@ -196,13 +214,13 @@ func queryTablesCount(dbname string, ch chan<- prometheus.Metric) (count float64
 		}
 	}
 
-	// errD := queryBloatTables(conn, ch)
+	// errD := queryBloatTables(ctx, conn, ch)
 	// if errD != nil {
 	// 	err=errD
 	// 	return
 	// }
 
-	// errF := querySkewTables(conn, ch)
+	// errF := querySkewTables(ctx, conn, ch)
 	// if errF != nil {
 	// 	err = errF
 	// 	return
@@ -211,8 +229,8 @@ func queryTablesCount(dbname string, ch chan<- prometheus.Metric) (count float64
 	return
 }
 
-func queryBloatTables(conn *sql.DB, ch chan<- prometheus.Metric) error {
-	rows, err := conn.Query(bloatTableSql)
+func queryBloatTables(ctx context.Context, conn *sql.DB, ch chan<- prometheus.Metric) error {
+	rows, err := conn.QueryContext(ctx, bloatTableSql)
 	logger.Infof("Query bloat tables sql: %s", bloatTableSql)
 
 	if err != nil {
@@ -238,8 +256,8 @@ func queryBloatTables(conn *sql.DB, ch chan<- prometheus.Metric) error {
 	return combineErr(errs...)
 }
 
-func querySkewTables(conn *sql.DB, ch chan<- prometheus.Metric) error {
-	rows, err := conn.Query(skewTableSql)
+func querySkewTables(ctx context.Context, conn *sql.DB, ch chan<- prometheus.Metric) error {
+	rows, err := conn.QueryContext(ctx, skewTableSql)
 	logger.Infof("Query skew tables sql: %s", skewTableSql)
 
 	if err != nil {
@@ -265,8 +283,8 @@ func querySkewTables(conn *sql.DB, ch chan<- prometheus.Metric) error {
 	return combineErr(errs...)
 }
 
-func queryHitCacheRate(db *sql.DB, ch chan<- prometheus.Metric) error {
-	rows, err := db.Query(hitCacheRateSql)
+func queryHitCacheRate(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx, hitCacheRateSql)
 	logger.Infof("Query Database: %s", hitCacheRateSql)
 
 	if err != nil {
@@ -287,8 +305,8 @@ func queryHitCacheRate(db *sql.DB, ch chan<- prometheus.Metric) error {
 	return nil
 }
 
-func queryTxCommitRate(db *sql.DB, ch chan<- prometheus.Metric) error {
-	rows, err := db.Query(txCommitRateSql)
+func queryTxCommitRate(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx, txCommitRateSql)
 	logger.Infof("Query Database: %s", txCommitRateSql)
 
 	if err != nil {
@@ -0,0 +1,116 @@
+package collector
+
+import (
+	"sync"
+	"time"
+)
+
+/**
+ * 明细数据的缓存，供/api/v1/<dataset>查询
+ * activity、locks等抓取器每次运行后将查询到的明细行发布到这里，
+ * 查询文本、pid等高基数的内容不必作为Prometheus指标的标签输出
+ */
+
+// 明细数据集名称，同时也是/api/v1/下的路径.
+const (
+	DatasetActivity      = "activity"
+	DatasetLocks         = "locks"
+	DatasetSessionMemory = "session-memory"
+	DatasetMasterLog     = "master-log"
+	DatasetBloat         = "bloat"
+	DatasetSkew          = "skew"
+)
+
+// 所有明细数据集.
+var Datasets = []string{DatasetActivity, DatasetLocks, DatasetSessionMemory, DatasetMasterLog, DatasetBloat, DatasetSkew}
+
+// 明细数据中的一行，键为字段名.
+type DetailRow map[string]string
+
+// 明细数据的一页查询结果.
+type DetailPage struct {
+	Dataset string      `json:"dataset"`
+	Updated time.Time   `json:"updated"`
+	Total   int         `json:"total"`
+	Offset  int         `json:"offset"`
+	Limit   int         `json:"limit"`
+	Rows    []DetailRow `json:"rows"`
+}
+
+type detailSet struct {
+	updated time.Time
+	rows    []DetailRow
+}
+
+// 采集器的明细数据缓存.
+type DetailStore struct {
+	mu   sync.RWMutex
+	sets map[string]detailSet
+}
+
+func newDetailStore() *DetailStore {
+	return &DetailStore{sets: make(map[string]detailSet)}
+}
+
+/**
+* 函数：publish
+* 功能：替换数据集的明细数据
+ */
+func (s *DetailStore) publish(dataset string, rows []DetailRow) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sets[dataset] = detailSet{updated: time.Now(), rows: rows}
+}
+
+/**
+* 函数：Query
+* 功能：按字段值过滤并分页查询数据集，filter中的每个字段都需要完全匹配，limit小于等于0时返回全部
+*      数据集尚未抓取过时第二个返回值为false
+ */
+func (s *DetailStore) Query(dataset string, filter map[string]string, offset, limit int) (DetailPage, bool) {
+	s.mu.RLock()
+	set, ok := s.sets[dataset]
+	s.mu.RUnlock()
+
+	page := DetailPage{Dataset: dataset, Offset: offset, Limit: limit, Rows: make([]DetailRow, 0)}
+
+	if !ok {
+		return page, false
+	}
+
+	page.Updated = set.updated
+
+	matched := make([]DetailRow, 0, len(set.rows))
+	for _, row := range set.rows {
+		if row.matches(filter) {
+			matched = append(matched, row)
+		}
+	}
+
+	page.Total = len(matched)
+
+	if offset < 0 {
+		offset = 0
+	}
+
+	if offset < len(matched) {
+		end := len(matched)
+		if limit > 0 && offset+limit < end {
+			end = offset + limit
+		}
+		page.Rows = matched[offset:end]
+	}
+
+	return page, true
+}
+
+func (r DetailRow) matches(filter map[string]string) bool {
+	for field, value := range filter {
+		if r[field] != value {
+			return false
+		}
+	}
+
+	return true
+}
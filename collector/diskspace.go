@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"database/sql"
 	"github.com/prometheus/client_golang/prometheus"
 	logger "github.com/prometheus/common/log"
@@ -18,19 +19,19 @@ const (
 )
 
 var (
-//	fsTotalDesc = prometheus.NewDesc(
+//	fsTotalDesc = newDesc(
 //		prometheus.BuildFQName(namespace, subSystemNode, "fs_total_bytes"),
 //		"Total bytes in the file system",
 //		[]string{"hostname", "filesystem"}, nil,
 //	)
 //
-//	fsUsedDesc = prometheus.NewDesc(
+//	fsUsedDesc = newDesc(
 //		prometheus.BuildFQName(namespace, subSystemNode, "fs_used_bytes"),
 //		"Total bytes used in the file system",
 //		[]string{"hostname", "filesystem"}, nil,
 //	)
 
-	fsAvailableDesc = prometheus.NewDesc(
+	fsAvailableDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "space_avail_gb"),
 		"Total GB available in the file system",
 		[]string{"hostname", "device"}, nil,
@@ -38,17 +39,19 @@ var (
 )
 
 func NewDiskScraper() Scraper {
-	return diskScraper{}
+	return &diskScraper{}
 }
 
-type diskScraper struct{}
+type diskScraper struct {
+	baseScraper
+}
 
 func (diskScraper) Name() string {
 	return "filesystem_scraper"
 }
 
-func (diskScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
-	rows, err := db.Query(fileSystemSql)
+func (diskScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+	rows, err := db.QueryContext(ctx, fileSystemSql)
 	logger.Infof("Query Database: %s",fileSystemSql)
 
 	if err != nil {
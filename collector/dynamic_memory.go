@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"database/sql"
 	"github.com/prometheus/client_golang/prometheus"
 	logger "github.com/prometheus/common/log"
@@ -16,13 +17,13 @@ const (
 )
 
 var (
-	dynamicMemUsedDesc = prometheus.NewDesc(
+	dynamicMemUsedDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "dynamic_memory_used_mb"),
 		"The amount of dynamic memory in MB allocated to query processes running on this segment host",
 		[]string{"hostname"}, nil,
 	)
 
-	dynamicMemAvailableDesc = prometheus.NewDesc(
+	dynamicMemAvailableDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "dynamic_memory_available_mb"),
 		"The amount of additional dynamic memory (in MB) available to the query processes running on this segment host",
 		[]string{"hostname"}, nil,
@@ -33,14 +34,16 @@ func NewDynamicMemoryScraper() Scraper {
 	return &dynamicMemoryScraper{}
 }
 
-type dynamicMemoryScraper struct{}
+type dynamicMemoryScraper struct {
+	baseScraper
+}
 
 func (dynamicMemoryScraper) Name() string {
 	return "dynamic_mem_scraper"
 }
 
-func (dynamicMemoryScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
-	rows, err := db.Query(dynamicMemorySql)
+func (dynamicMemoryScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+	rows, err := db.QueryContext(ctx, dynamicMemorySql)
 	logger.Infof("Query Database: %s",dynamicMemorySql)
 
 	if err != nil {
@@ -1,27 +1,119 @@
 package collector
 
-import "errors"
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// 抓取器错误的分类，作为hashdata_exporter_scraper_errors_total的class标签.
+const (
+	errorClassConnection = "connection"
+	errorClassTimeout    = "timeout"
+	errorClassSQL        = "sql"
+	errorClassScan       = "scan"
+	errorClassOther      = "other"
+)
+
+// combineErr组合后的多个错误，保留原始错误用于分类.
+type multiError []error
+
+func (m multiError) Error() string {
+	var errStr string
+	for _, err := range m {
+		if errStr == "" {
+			errStr += err.Error()
+		} else {
+			errStr += "; " + err.Error()
+		}
+	}
+
+	return errStr
+}
 
 /**
 * 函数：combineErr
 * 功能：error的组合
  */
 func combineErr(errs ...error) error {
-	var errStr string
+	combined := make(multiError, 0, len(errs))
 	for _, err := range errs {
 		if err != nil {
-			if errStr == "" {
-				errStr += err.Error()
-			} else {
-				errStr += "; " + err.Error()
-			}
-
+			combined = append(combined, err)
 		}
 	}
 
-	if errStr == "" {
+	if len(combined) == 0 {
 		return nil
+	} else if len(combined) == 1 {
+		return combined[0]
 	} else {
-		return errors.New(errStr)
+		return combined
+	}
+}
+
+/**
+* 函数：classifyError
+* 功能：错误分类，组合的错误按第一个错误分类
+ */
+func classifyError(err error) string {
+	var m multiError
+	if errors.As(err, &m) {
+		return classifyError(m[0])
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return errorClassTimeout
 	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch {
+		case pqErr.Code == "57014": // query_canceled，包括statement_timeout和cancel请求
+			return errorClassTimeout
+		case pqErr.Code.Class() == "08", pqErr.Code.Class() == "57", pqErr.Code.Class() == "28":
+			return errorClassConnection
+		default:
+			return errorClassSQL
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return errorClassTimeout
+		}
+		return errorClassConnection
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) || errors.Is(err, errConnManagerClosed) || strings.HasPrefix(err.Error(), "sql: database is closed") {
+		return errorClassConnection
+	}
+
+	if strings.HasPrefix(err.Error(), "sql: Scan error") || strings.HasPrefix(err.Error(), "sql: expected") {
+		return errorClassScan
+	}
+
+	return errorClassOther
+}
+
+// 抓取器需要区分处理的SQLSTATE.
+const (
+	undefinedTable  pq.ErrorCode = "42P01"
+	undefinedObject pq.ErrorCode = "42704"
+)
+
+/**
+* 函数：hasErrorCode
+* 功能：判断错误是否为指定SQLSTATE的数据库错误
+ */
+func hasErrorCode(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+
+	return errors.As(err, &pqErr) && pqErr.Code == code
 }
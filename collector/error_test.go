@@ -0,0 +1,89 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestClassifyError(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"deadline exceeded", context.DeadlineExceeded, errorClassTimeout},
+		{"canceled", context.Canceled, errorClassTimeout},
+		{"wrapped deadline", fmt.Errorf("bloat: %w", context.DeadlineExceeded), errorClassTimeout},
+		{"statement timeout", &pq.Error{Code: "57014"}, errorClassTimeout},
+		{"connection failure", &pq.Error{Code: "08006"}, errorClassConnection},
+		{"admin shutdown", &pq.Error{Code: "57P01"}, errorClassConnection},
+		{"authentication", &pq.Error{Code: "28P01"}, errorClassConnection},
+		{"permission denied", &pq.Error{Code: "42501"}, errorClassSQL},
+		{"undefined table", &pq.Error{Code: "42P01"}, errorClassSQL},
+		{"undefined object", &pq.Error{Code: "42704"}, errorClassSQL},
+		{"net timeout", &net.OpError{Op: "read", Err: timeoutError{}}, errorClassTimeout},
+		{"net refused", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, errorClassConnection},
+		{"bad connection", driver.ErrBadConn, errorClassConnection},
+		{"connection done", sql.ErrConnDone, errorClassConnection},
+		{"closed database", errors.New("sql: database is closed"), errorClassConnection},
+		{"closed connection manager", fmt.Errorf("query dw_sales failed: %w", errConnManagerClosed), errorClassConnection},
+		{"scan", errors.New("sql: Scan error on column index 0"), errorClassScan},
+		{"destination count", errors.New("sql: expected 2 destination arguments in Scan, not 1"), errorClassScan},
+		{"other", errors.New("unexpected"), errorClassOther},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if class := classifyError(c.err); class != c.expected {
+				t.Errorf("classifyError(%v) = %q, expected %q", c.err, class, c.expected)
+			}
+		})
+	}
+}
+
+func TestCombineErr(t *testing.T) {
+	first := &pq.Error{Code: "42501", Message: "permission denied"}
+	second := context.DeadlineExceeded
+
+	if err := combineErr(nil, nil); err != nil {
+		t.Errorf("combineErr(nil, nil) = %v, expected nil", err)
+	}
+
+	if err := combineErr(nil, first); err != first {
+		t.Errorf("combineErr with a single error = %v, expected the error itself", err)
+	}
+
+	err := combineErr(first, nil, second)
+
+	var m multiError
+	if !errors.As(err, &m) || len(m) != 2 {
+		t.Fatalf("combineErr(first, nil, second) = %#v, expected a multiError of 2 errors", err)
+	}
+
+	if expected := first.Error() + "; " + second.Error(); err.Error() != expected {
+		t.Errorf("Error() = %q, expected %q", err.Error(), expected)
+	}
+
+	// 组合的错误按第一个错误分类
+	if class := classifyError(err); class != errorClassSQL {
+		t.Errorf("classifyError(combined) = %q, expected %q", class, errorClassSQL)
+	}
+
+	if class := classifyError(combineErr(second, first)); class != errorClassTimeout {
+		t.Errorf("classifyError(combined) = %q, expected %q", class, errorClassTimeout)
+	}
+}
+
+// 超时的网络错误.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
@@ -15,7 +15,13 @@ type ExporterMetrics struct {
 	totalScraped   prometheus.Counter
 	totalError     prometheus.Counter
 	scrapeDuration prometheus.Gauge
-	hdwUp    prometheus.Gauge
+	hdwUp          prometheus.Gauge
+
+	scraperDuration *prometheus.GaugeVec
+	scraperSuccess  *prometheus.GaugeVec
+	scraperErrors   *prometheus.CounterVec
+	seriesDropped   *prometheus.CounterVec
+	redactions      *prometheus.CounterVec
 }
 
 /**
@@ -55,5 +61,66 @@ func NewMetrics() *ExporterMetrics {
 				Help:      "Whether hashdata cluster is reachable",
 			},
 		),
+		scraperDuration: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: subsystemExporter,
+				Name:      "scraper_duration_seconds",
+				Help:      "Elapsed of the last run of each scraper",
+			},
+			[]string{"scraper"},
+		),
+		scraperSuccess: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: subsystemExporter,
+				Name:      "scraper_success",
+				Help:      "Whether the last run of each scraper succeeded",
+			},
+			[]string{"scraper"},
+		),
+		scraperErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystemExporter,
+				Name:      "scraper_errors_total",
+				Help:      "Total errors of each scraper by error class (connection, timeout, sql, scan, other)",
+			},
+			[]string{"scraper", "class"},
+		),
+		seriesDropped: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystemExporter,
+				Name:      "series_dropped_total",
+				Help:      "Total detail series dropped by the max_rows limit of each scraper",
+			},
+			[]string{"scraper"},
+		),
+		redactions: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystemExporter,
+				Name:      "redactions_total",
+				Help:      "Total label and detail values of each scraper in which sensitive text was redacted",
+			},
+			[]string{"scraper"},
+		),
 	}
 }
+
+/**
+* 函数：describe
+* 功能：输出采集器自身指标的描述
+ */
+func (m *ExporterMetrics) describe(ch chan<- *prometheus.Desc) {
+	ch <- m.hdwUp.Desc()
+	ch <- m.scrapeDuration.Desc()
+	ch <- m.totalScraped.Desc()
+	ch <- m.totalError.Desc()
+	m.scraperDuration.Describe(ch)
+	m.scraperSuccess.Describe(ch)
+	m.scraperErrors.Describe(ch)
+	m.seriesDropped.Describe(ch)
+	m.redactions.Describe(ch)
+}
@@ -0,0 +1,211 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+/**
+ * 测试使用的内存数据库：按SQL中包含的片段返回预设的结果，并记录执行过的SQL
+ */
+
+type fakeResult struct {
+	match   string
+	columns []string
+	rows    [][]driver.Value
+	err     error
+}
+
+type fakeDB struct {
+	mu      sync.Mutex
+	results []fakeResult
+	queries []string
+}
+
+/**
+* 函数：newFakeDB
+* 功能：创建内存数据库，返回的*sql.DB在查询时按on、fail注册的顺序匹配结果
+ */
+func newFakeDB() (*fakeDB, *sql.DB) {
+	f := &fakeDB{}
+
+	return f, sql.OpenDB(f)
+}
+
+// 包含match的SQL返回指定的列和行.
+func (f *fakeDB) on(match string, columns []string, rows ...[]driver.Value) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.results = append(f.results, fakeResult{match: match, columns: columns, rows: rows})
+}
+
+// 包含match的SQL返回错误.
+func (f *fakeDB) fail(match string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.results = append(f.results, fakeResult{match: match, err: err})
+}
+
+// 返回包含match的已执行SQL的个数.
+func (f *fakeDB) executed(match string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n := 0
+	for _, query := range f.queries {
+		if strings.Contains(query, match) {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (f *fakeDB) query(query string) (driver.Rows, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.queries = append(f.queries, query)
+
+	for _, r := range f.results {
+		if strings.Contains(query, r.match) {
+			if r.err != nil {
+				return nil, r.err
+			}
+
+			return &fakeRows{columns: r.columns, rows: r.rows}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unexpected query: %s", query)
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{db: f}, nil
+}
+
+func (f *fakeDB) Driver() driver.Driver {
+	return fakeDriver{f}
+}
+
+type fakeDriver struct {
+	db *fakeDB
+}
+
+func (d fakeDriver) Open(string) (driver.Conn, error) {
+	return &fakeConn{db: d.db}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepare is not supported")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+func (c *fakeConn) Ping(context.Context) error {
+	return nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	return c.db.query(query)
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+
+	return nil
+}
+
+// 输出固定指标的Collector.
+type metricsCollector []prometheus.Metric
+
+func (m metricsCollector) Describe(chan<- *prometheus.Desc) {}
+
+func (m metricsCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, metric := range m {
+		ch <- metric
+	}
+}
+
+/**
+* 函数：scrapeMetrics
+* 功能：运行一次抓取器，返回按“名称{标签}”索引的指标值，同时返回抓取器的错误
+ */
+func scrapeMetrics(t *testing.T, scraper Scraper, db *sql.DB, ver int) (map[string]float64, error) {
+	ch := make(chan prometheus.Metric)
+	metrics := make(metricsCollector, 0)
+	done := make(chan struct{})
+
+	go func() {
+		for m := range ch {
+			metrics = append(metrics, m)
+		}
+		close(done)
+	}()
+
+	err := scraper.Scrape(context.Background(), db, ch, ver)
+	close(ch)
+	<-done
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(metrics)
+
+	families, gatherErr := registry.Gather()
+	if gatherErr != nil {
+		t.Fatal(gatherErr)
+	}
+
+	values := make(map[string]float64)
+
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			labels := make([]string, 0, len(m.GetLabel()))
+			for _, label := range m.GetLabel() {
+				labels = append(labels, fmt.Sprintf("%s=%q", label.GetName(), label.GetValue()))
+			}
+
+			values[family.GetName()+"{"+strings.Join(labels, ",")+"}"] = m.GetGauge().GetValue() + m.GetCounter().GetValue()
+		}
+	}
+
+	return values, err
+}
@@ -0,0 +1,204 @@
+package collector
+
+import (
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+)
+
+/**
+ * SQL语句指纹：将查询文本中的常量替换为?并合并空白，得到只反映语句结构的文本，
+ * 避免客户编号、邮箱等常量出现在Prometheus标签中，同时可以按语句结构聚合
+ */
+
+// 只包含常量的IN列表、VALUES列表，例如(?, ?, ?)，负数为-?.
+var placeholderListRegex = regexp.MustCompile(`\(\s*-?\?(?:\s*,\s*-?\?)+\s*\)`)
+
+/**
+* 函数：fingerprintQuery
+* 功能：返回规范化后的查询文本以及其16位十六进制的哈希值
+ */
+func fingerprintQuery(query string) (string, string) {
+	normalized := normalizeQuery(query)
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(normalized))
+
+	return normalized, fmt.Sprintf("%016x", h.Sum64())
+}
+
+/**
+* 函数：normalizeQuery
+* 功能：将字符串、数字、$$字符串常量替换为?，去掉注释并合并空白，常量列表合并为(?)
+*      带引号的标识符和$1形式的参数保持不变，pg_stat_activity截断的查询中未结束的常量同样替换为?
+ */
+func normalizeQuery(query string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+
+	space := false
+	writeSpace := func() {
+		if b.Len() > 0 {
+			space = true
+		}
+	}
+	write := func(s string) {
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteString(s)
+	}
+
+	prevIdent := false
+
+	for i := 0; i < len(query); {
+		c := query[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			writeSpace()
+			i++
+			prevIdent = false
+			continue
+
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			i += end
+			writeSpace()
+			prevIdent = false
+			continue
+
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 4
+			}
+			writeSpace()
+			prevIdent = false
+			continue
+
+		case c == '\'':
+			i = skipQuoted(query, i, '\'', false)
+			write("?")
+			prevIdent = false
+			continue
+
+		case c == '"':
+			end := skipQuoted(query, i, '"', false)
+			write(query[i:end])
+			i = end
+			prevIdent = true
+			continue
+
+		case c == '$' && !prevIdent:
+			if tag, ok := dollarTag(query, i); ok {
+				end := strings.Index(query[i+len(tag):], tag)
+				if end < 0 {
+					i = len(query)
+				} else {
+					i += len(tag) + end + len(tag)
+				}
+				write("?")
+				prevIdent = false
+				continue
+			}
+
+		case isDigit(c) && !prevIdent, c == '.' && !prevIdent && i+1 < len(query) && isDigit(query[i+1]):
+			i = skipNumber(query, i)
+			write("?")
+			prevIdent = false
+			continue
+
+		case (c == 'E' || c == 'e' || c == 'B' || c == 'b' || c == 'X' || c == 'x') && !prevIdent &&
+			i+1 < len(query) && query[i+1] == '\'':
+			// E'...'、B'...'、X'...'形式的常量
+			i = skipQuoted(query, i+1, '\'', c == 'E' || c == 'e')
+			write("?")
+			prevIdent = false
+			continue
+		}
+
+		write(query[i : i+1])
+		prevIdent = isIdentChar(c)
+		i++
+	}
+
+	return placeholderListRegex.ReplaceAllString(b.String(), "(?)")
+}
+
+// 跳过以quote开始的带引号文本，连续两个引号视为转义，backslash为true时反斜杠同样视为转义（E'...'），
+// 返回结束引号之后的位置.
+func skipQuoted(query string, start int, quote byte, backslash bool) int {
+	for i := start + 1; i < len(query); i++ {
+		if backslash && query[i] == '\\' {
+			i++
+			continue
+		}
+
+		if query[i] == quote {
+			if i+1 < len(query) && query[i+1] == quote {
+				i++
+				continue
+			}
+
+			return i + 1
+		}
+	}
+
+	return len(query)
+}
+
+// 返回$tag$形式的开始标记，$1等参数不是开始标记.
+func dollarTag(query string, start int) (string, bool) {
+	for i := start + 1; i < len(query); i++ {
+		c := query[i]
+		if c == '$' {
+			return query[start : i+1], true
+		}
+
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > start+1 && isDigit(c)) {
+			return "", false
+		}
+	}
+
+	return "", false
+}
+
+// 跳过数字常量，包括小数和科学计数法，返回其后的位置.
+func skipNumber(query string, start int) int {
+	i := start
+	for i < len(query) && (isDigit(query[i]) || query[i] == '.') {
+		i++
+	}
+
+	if i < len(query) && (query[i] == 'e' || query[i] == 'E') {
+		j := i + 1
+		if j < len(query) && (query[j] == '+' || query[j] == '-') {
+			j++
+		}
+
+		if j < len(query) && isDigit(query[j]) {
+			i = j
+			for i < len(query) && isDigit(query[i]) {
+				i++
+			}
+		}
+	}
+
+	return i
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
@@ -0,0 +1,47 @@
+package collector
+
+import "testing"
+
+func TestNormalizeQuery(t *testing.T) {
+	cases := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{"numbers and strings", "select * from t where id = 42 and email = 'a@b.com'", "select * from t where id = ? and email = ?"},
+		{"in list", "select * from t where x in (1, 2, 3)", "select * from t where x in (?)"},
+		{"values list", "insert into t values ('a', 1.5e3, -2)", "insert into t values (?)"},
+		{"doubled quote", "select 'it''s' from t", "select ? from t"},
+		{"escape string", `select E'a\'b', B'101', X'1F' from t`, "select ?, ?, ? from t"},
+		{"dollar quoted", "select $$a 'b'$$, $fn$x$fn$ from t", "select ?, ? from t"},
+		{"parameters", "select * from t where id = $1", "select * from t where id = $1"},
+		{"identifiers with digits", "select col1, t2.c from t2", "select col1, t2.c from t2"},
+		{"quoted identifier", `select "Col 1" from "T"`, `select "Col 1" from "T"`},
+		{"comments and whitespace", "select 1 -- one\n  from /* c */\tt", "select ? from t"},
+		{"truncated literal", "select * from t where name = 'abc", "select * from t where name = ?"},
+		{"truncated comment", "select 1 /* abc", "select ?"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if normalized := normalizeQuery(c.query); normalized != c.expected {
+				t.Errorf("normalizeQuery(%q) = %q, expected %q", c.query, normalized, c.expected)
+			}
+		})
+	}
+}
+
+func TestFingerprintQuery(t *testing.T) {
+	fingerprint, hash := fingerprintQuery("select * from t where id = 1")
+	if fingerprint != "select * from t where id = ?" || len(hash) != 16 {
+		t.Fatalf("fingerprintQuery() = %q, %q", fingerprint, hash)
+	}
+
+	if _, other := fingerprintQuery("select  *  from t where id = 2"); other != hash {
+		t.Errorf("queries with the same structure have different hashes %q and %q", hash, other)
+	}
+
+	if _, other := fingerprintQuery("select * from t where name = 1"); other == hash {
+		t.Errorf("queries with different structure have the same hash %q", hash)
+	}
+}
@@ -0,0 +1,125 @@
+package collector
+
+import (
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+/**
+ * 高基数保护：限制*_detail指标的行数和标签长度，避免查询文本、大量膨胀表等产生过大的抓取结果
+ * 抓取器需要按重要程度排序后输出，超出max_rows的行被丢弃并计入hashdata_exporter_series_dropped_total
+ */
+
+const (
+	// *_detail指标默认最多输出的行数.
+	defaultMaxRows = 500
+
+	// 标签值默认的最大长度（字符数），超出部分截断.
+	defaultMaxLabelLength = 1024
+
+	// 截断后的标签值的后缀.
+	truncatedSuffix = "..."
+)
+
+// 单个抓取器一次运行使用的保护器.
+type seriesGuard struct {
+	maxRows        int
+	maxLabelLength int
+	dropped        prometheus.Counter
+	redactor       *redactor
+	redactions     prometheus.Counter
+
+	rows int
+}
+
+/**
+* 函数：guard
+* 功能：根据抓取器的选项创建保护器，选项未配置时使用默认值
+ */
+func (e *scraperEnv) guard(scraper string, opts ScraperOptions) *seriesGuard {
+	g := &seriesGuard{
+		maxRows:        opts.MaxRows,
+		maxLabelLength: opts.MaxLabelLength,
+		dropped:        e.seriesDropped.WithLabelValues(scraper),
+		redactor:       e.redactor,
+		redactions:     e.redactions.WithLabelValues(scraper),
+	}
+
+	if g.maxRows == 0 {
+		g.maxRows = defaultMaxRows
+	}
+
+	if g.maxLabelLength == 0 {
+		g.maxLabelLength = defaultMaxLabelLength
+	}
+
+	return g
+}
+
+/**
+* 函数：emit
+* 功能：未超出行数限制时对标签值脱敏、截断后输出指标，超出时丢弃并计数
+ */
+func (g *seriesGuard) emit(ch chan<- prometheus.Metric, desc *prometheus.Desc, value float64, labelValues ...string) {
+	if !g.next() {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, g.labels(labelValues...)...)
+}
+
+/**
+* 函数：next
+* 功能：记录输出一行，超出行数限制时返回false并计数，用于一行输出多个指标的抓取器
+ */
+func (g *seriesGuard) next() bool {
+	g.rows++
+	if g.rows > g.maxRows {
+		g.dropped.Inc()
+		return false
+	}
+
+	return true
+}
+
+/**
+* 函数：labels
+* 功能：对标签值脱敏并截断
+ */
+func (g *seriesGuard) labels(labelValues ...string) []string {
+	for i, v := range labelValues {
+		if redacted, ok := g.redactor.redact(v); ok {
+			v = redacted
+			g.redactions.Inc()
+		}
+
+		labelValues[i] = truncateLabel(v, g.maxLabelLength)
+	}
+
+	return labelValues
+}
+
+/**
+* 函数：truncateLabel
+* 功能：按字符数截断标签值，截断时以...结尾，总长度不超过max
+ */
+func truncateLabel(value string, max int) string {
+	if utf8.RuneCountInString(value) <= max {
+		return value
+	}
+
+	keep := max - len(truncatedSuffix)
+	if keep < 0 {
+		keep = 0
+	}
+
+	for i := range value {
+		if keep == 0 {
+			return value[:i] + truncatedSuffix
+		}
+		keep--
+	}
+
+	return value
+}
@@ -0,0 +1,73 @@
+package collector
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestTruncateLabel(t *testing.T) {
+	cases := []struct {
+		name     string
+		value    string
+		max      int
+		expected string
+	}{
+		{"short", "select 1", 10, "select 1"},
+		{"exact", "0123456789", 10, "0123456789"},
+		{"long", "0123456789abc", 10, "0123456..."},
+		{"multibyte", "数据库数据库数据库", 5, "数据..."},
+		{"max equals suffix", "abcdef", 3, "..."},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			truncated := truncateLabel(c.value, c.max)
+			if truncated != c.expected {
+				t.Errorf("truncateLabel(%q, %d) = %q, expected %q", c.value, c.max, truncated, c.expected)
+			}
+
+			if !utf8.ValidString(truncated) || utf8.RuneCountInString(truncated) > c.max {
+				t.Errorf("truncateLabel(%q, %d) = %q is invalid or too long", c.value, c.max, truncated)
+			}
+		})
+	}
+}
+
+func TestSeriesGuard(t *testing.T) {
+	redactor, err := newRedactor(RedactionOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dropped := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "dropped"}, []string{"scraper"})
+	redactions := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "redactions"}, []string{"scraper"})
+	env := &scraperEnv{seriesDropped: dropped, redactor: redactor, redactions: redactions}
+
+	g := env.guard("test", ScraperOptions{MaxRows: 2, MaxLabelLength: 20})
+
+	for i, expected := range []bool{true, true, false, false} {
+		if ok := g.next(); ok != expected {
+			t.Errorf("next() #%d = %v, expected %v", i, ok, expected)
+		}
+	}
+
+	labels := g.labels("password=secret", "select * from a_very_long_table_name")
+	if labels[0] != "password=***" || labels[1] != "select * from a_v..." {
+		t.Errorf("labels() = %q", labels)
+	}
+
+	if v := testutil.ToFloat64(dropped.WithLabelValues("test")); v != 2 {
+		t.Errorf("dropped = %v, expected 2", v)
+	}
+
+	if v := testutil.ToFloat64(redactions.WithLabelValues("test")); v != 1 {
+		t.Errorf("redactions = %v, expected 1", v)
+	}
+
+	if g.maxRows != 2 || env.guard("test", ScraperOptions{}).maxRows != defaultMaxRows {
+		t.Errorf("unexpected max rows")
+	}
+}
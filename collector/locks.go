@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
@@ -68,10 +69,17 @@ const (
 )
 
 var (
-	locksDesc = prometheus.NewDesc(
+	locksDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemServer, "locks_table_detail"),
 		"Table locks detail for hashdata database",
-		[]string{"pid", "datname", "usename", "locktype", "mode", "application_name", "state", "lock_satus", "query"},
+		[]string{"pid", "datname", "usename", "locktype", "mode", "application_name", "state", "lock_satus", "query", "query_hash"},
+		nil,
+	)
+
+	locksCountDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "locks_count"),
+		"Number of locks held or awaited by database, lock mode and lock status",
+		[]string{"datname", "mode", "lock_status"},
 		nil,
 	)
 )
@@ -80,19 +88,31 @@ func NewLocksScraper() Scraper {
 	return &locksScraper{}
 }
 
-type locksScraper struct{}
+type locksScraper struct {
+	baseScraper
+}
 
 func (locksScraper) Name() string {
 	return "locks_scraper"
 }
 
-func (locksScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+func (s *locksScraper) Configure(opts ScraperOptions) error {
+	if err := opts.check(supportedOptions{detailMetrics: true}); err != nil {
+		return err
+	}
+
+	s.opts = opts
+
+	return nil
+}
+
+func (s *locksScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
 	querySql := locksQuerySql_V6
 	if ver > 3 && ver < 6 {
 		querySql = locksQuerySql_V5
 	}
 
-	rows, err := db.Query(querySql)
+	rows, err := db.QueryContext(ctx, querySql)
 	logger.Infof("Query Database: %s", querySql)
 
 	if err != nil {
@@ -102,10 +122,14 @@ func (locksScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver int) err
 
 	defer rows.Close()
 
+	details := make([]DetailRow, 0)
+	guard := s.env.guard(s.Name(), s.opts)
+	counts := make(map[[3]string]float64)
+
 	for rows.Next() {
 		var pid, datname, usename, locktype, mode, application_name, state, lock_satus, query string
 		var startTime time.Time
-		var count int64
+		var count float64
 
 		err = rows.Scan(&pid,
 			&datname,
@@ -123,8 +147,39 @@ func (locksScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver int) err
 			return err
 		}
 
-		ch <- prometheus.MustNewConstMetric(locksDesc, prometheus.GaugeValue, float64(startTime.UTC().Unix()), pid, datname, usename, locktype, mode, application_name, state, lock_satus, query)
+		fingerprint, queryHash := fingerprintQuery(query)
+
+		counts[[3]string{datname, mode, lock_satus}] += count
+
+		details = append(details, DetailRow{
+			"pid":              pid,
+			"datname":          datname,
+			"usename":          usename,
+			"locktype":         locktype,
+			"mode":             mode,
+			"application_name": application_name,
+			"state":            state,
+			"lock_status":      lock_satus,
+			"query":            query,
+			"fingerprint":      fingerprint,
+			"query_hash":       queryHash,
+			"start_time":       startTime.UTC().Format(time.RFC3339),
+		})
+
+		if s.opts.detailMetrics() {
+			guard.emit(ch, locksDesc, float64(startTime.UTC().Unix()), pid, datname, usename, locktype, mode, application_name, state, lock_satus, fingerprint, queryHash)
+		}
 	}
 
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for key, count := range counts {
+		ch <- prometheus.MustNewConstMetric(locksCountDesc, prometheus.GaugeValue, count, key[0], key[1], key[2])
+	}
+
+	s.env.publish(s.Name(), DatasetLocks, details)
+
 	return nil
 }
@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
@@ -14,13 +15,13 @@ const (
     from gp_toolkit.__gp_log_master_ext a join gp_toolkit.gp_log_command_timings b
 	on a.logsession = b.logsession
 	and a.logcmdcount = b.logcmdcount
-    where logtime > now() - interval '1 day'
+    where logtime > now() - $1 * interval '1 hour'
 	and (
 		lower(logmessage)  like '%delete from%' 
 		or lower(logmessage) like '%drop %' 
 		or lower(logmessage) like '%truncate %' 
 		or logseverity = 'ERROR'
-		or b.logduration > '1 minute'
+		or b.logduration > $2 * interval '1 second'
 	)
 	and a.logmessage not like '%gp_toolkit%'
 	and a.logmessage not like 'successfully allocated xid%'
@@ -29,27 +30,46 @@ const (
 )
 
 var (
-	masterLogDesc = prometheus.NewDesc(
+	masterLogDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemServer, "master_log_detail"),
 		"Master log for HashData database",
 		[]string{"logtime", "loguser", "logdatabase", "loghost", "logsession", "logcmdcount", "logseverity", "logmessage", "logdebug", "logduration"},
 		nil,
 	)
+
+	masterLogEntriesDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "master_log_entries"),
+		"Number of notable master log entries within the lookback window by severity",
+		[]string{"logseverity"},
+		nil,
+	)
 )
 
 func NewMasterLogScraper() Scraper {
 	return &masterLogScraper{}
 }
 
-type masterLogScraper struct{}
+type masterLogScraper struct {
+	baseScraper
+}
 
 func (masterLogScraper) Name() string {
 	return "masterLogScraper"
 }
 
-func (masterLogScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+func (s *masterLogScraper) Configure(opts ScraperOptions) error {
+	if err := opts.check(supportedOptions{detailMetrics: true, thresholds: []string{"lookback_hours", "min_duration_seconds"}}); err != nil {
+		return err
+	}
+
+	s.opts = opts
+
+	return nil
+}
+
+func (s *masterLogScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
 
-	rows, err := db.Query(masterLogSql)
+	rows, err := db.QueryContext(ctx, masterLogSql, s.opts.threshold("lookback_hours", 24), s.opts.threshold("min_duration_seconds", 60))
 	logger.Infof("Query Database: %s", masterLogSql)
 
 	if err != nil {
@@ -58,6 +78,10 @@ func (masterLogScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver int)
 
 	defer rows.Close()
 
+	details := make([]DetailRow, 0)
+	guard := s.env.guard(s.Name(), s.opts)
+	entries := make(map[string]float64)
+
 	for rows.Next() {
 		var logtime, loguser, logdatabase, loghost, logsession, logcmdcount, logseverity, logmessage, logdebug, logduration string
 		var currentTime time.Time
@@ -77,7 +101,26 @@ func (masterLogScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver int)
 			return err
 		}
 
-		ch <- prometheus.MustNewConstMetric(masterLogDesc, prometheus.GaugeValue,
+		entries[logseverity]++
+
+		details = append(details, DetailRow{
+			"logtime":     logtime,
+			"loguser":     loguser,
+			"logdatabase": logdatabase,
+			"loghost":     loghost,
+			"logsession":  logsession,
+			"logcmdcount": logcmdcount,
+			"logseverity": logseverity,
+			"logmessage":  logmessage,
+			"logdebug":    logdebug,
+			"logduration": logduration,
+		})
+
+		if !s.opts.detailMetrics() {
+			continue
+		}
+
+		guard.emit(ch, masterLogDesc,
 			float64(currentTime.UTC().Unix()),
 			logtime,
 			loguser,
@@ -91,5 +134,15 @@ func (masterLogScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver int)
 			logduration)
 	}
 
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for severity, count := range entries {
+		ch <- prometheus.MustNewConstMetric(masterLogEntriesDesc, prometheus.GaugeValue, count, severity)
+	}
+
+	s.env.publish(s.Name(), DatasetMasterLog, details)
+
 	return nil
 }
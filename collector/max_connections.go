@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -18,7 +19,7 @@ const (
 )
 
 var (
-	maxConnDesc = prometheus.NewDesc(
+	maxConnDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemCluster, "max_connections"),
 		"Max connection of hashdata cluster",
 		nil, nil,
@@ -26,23 +27,25 @@ var (
 )
 
 func NewMaxConnScraper() Scraper {
-	return maxConnScraper{}
+	return &maxConnScraper{}
 }
 
-type maxConnScraper struct{}
+type maxConnScraper struct {
+	baseScraper
+}
 
 func (maxConnScraper) Name() string {
 	return "max_connection_scraper"
 }
 
-func (maxConnScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
-	maxConn, err := showConnections(db, maxConnectionsSql)
+func (maxConnScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+	maxConn, err := showConnections(ctx, db, maxConnectionsSql)
 
 	if err != nil {
 		return err
 	}
 
-	reserved, err := showConnections(db, suReservedSql)
+	reserved, err := showConnections(ctx, db, suReservedSql)
 
 	if err != nil {
 		logger.Warn(err.Error())
@@ -54,8 +57,8 @@ func (maxConnScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver int) e
 	return nil
 }
 
-func showConnections(db *sql.DB, sql string) (conn float64, err error) {
-	rows, err := db.Query(sql)
+func showConnections(ctx context.Context, db *sql.DB, sql string) (conn float64, err error) {
+	rows, err := db.QueryContext(ctx, sql)
 	logger.Infof("Query Database: %s",sql)
 
 	if err != nil {
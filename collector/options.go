@@ -0,0 +1,316 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+/**
+ * 抓取器的配置选项，来源于配置文件中每个抓取器的配置项
+ */
+
+// 抓取器的配置选项.
+type ScraperOptions struct {
+	// 抓取器的超时时间，超时后正在执行的查询会被取消，为0时不限制.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+
+	// 大于0时抓取器在后台按此间隔运行，Prometheus抓取时输出缓存的最近一次结果.
+	Interval time.Duration `yaml:"interval,omitempty"`
+
+	// 抓取器使用的阈值，具体的名称由各个抓取器定义.
+	Thresholds map[string]float64 `yaml:"thresholds,omitempty"`
+
+	// 需要逐个数据库抓取的抓取器使用的数据库过滤列表.
+	Databases DatabaseFilter `yaml:"databases,omitempty"`
+
+	// 按表抓取的抓取器需要抓取的表.
+	Tables TableFilter `yaml:"tables,omitempty"`
+
+	// 输出明细数据的抓取器是否同时输出高基数的*_detail指标，未配置时不输出，只输出聚合指标.
+	// 明细数据始终可以通过/api/v1/<dataset>查询.
+	DetailMetrics *bool `yaml:"detail_metrics,omitempty"`
+
+	// resource_group_scraper是否输出每个segment的CPU和内存使用.
+	PerSegment bool `yaml:"per_segment,omitempty"`
+
+	// stat_database_scraper是否汇总master和所有segment上的值.
+	AggregateSegments bool `yaml:"aggregate_segments,omitempty"`
+
+	// *_detail指标最多输出的行数，为0时使用默认值500.
+	MaxRows int `yaml:"max_rows,omitempty"`
+
+	// *_detail指标标签值的最大长度（字符数），为0时使用默认值1024.
+	MaxLabelLength int `yaml:"max_label_length,omitempty"`
+}
+
+// 数据库过滤列表，Include、Exclude为正则表达式，需要匹配整个数据库名称，Include为空时表示所有数据库.
+type DatabaseFilter struct {
+	Include []string `yaml:"include,omitempty"`
+	Exclude []string `yaml:"exclude,omitempty"`
+
+	// 为true时跳过datallowconn为false（不允许连接）的数据库.
+	SkipDisallowedConnections bool `yaml:"skip_disallowed_connections,omitempty"`
+}
+
+// 表过滤列表，Include、Exclude为正则表达式，需要匹配整个schema.table名称.
+type TableFilter struct {
+	Include []string `yaml:"include,omitempty"`
+	Exclude []string `yaml:"exclude,omitempty"`
+}
+
+// 已编译的数据库和表过滤正则表达式，键为配置中的表达式.
+var namePatterns sync.Map
+
+// 支持配置选项的抓取器.
+type ConfigurableScraper interface {
+	Scraper
+
+	// 应用配置文件中的选项，选项不合法时返回错误.
+	Configure(opts ScraperOptions) error
+}
+
+/**
+* 函数：IsZero
+* 功能：判断选项是否全部为默认值
+ */
+func (o ScraperOptions) IsZero() bool {
+	return o.Timeout == 0 && o.Interval == 0 && len(o.Thresholds) == 0 && o.Databases.IsZero() && o.Tables.IsZero() && o.DetailMetrics == nil &&
+		o.MaxRows == 0 && o.MaxLabelLength == 0 && !o.PerSegment && !o.AggregateSegments
+}
+
+/**
+* 函数：timeout
+* 功能：返回配置的超时时间，未配置时返回默认值
+ */
+func (o ScraperOptions) timeout(def time.Duration) time.Duration {
+	if o.Timeout > 0 {
+		return o.Timeout
+	}
+
+	return def
+}
+
+/**
+* 函数：threshold
+* 功能：返回指定名称的阈值，未配置时返回默认值
+ */
+func (o ScraperOptions) threshold(name string, def float64) float64 {
+	if v, ok := o.Thresholds[name]; ok {
+		return v
+	}
+
+	return def
+}
+
+/**
+* 函数：detailMetrics
+* 功能：是否输出*_detail指标，需要配置detail_metrics: true
+ */
+func (o ScraperOptions) detailMetrics() bool {
+	return o.DetailMetrics != nil && *o.DetailMetrics
+}
+
+// 抓取器支持的选项，用于校验配置文件中的选项.
+type supportedOptions struct {
+	// 数据库过滤和表过滤.
+	databases bool
+	tables    bool
+
+	// 输出*_detail指标，同时支持max_rows和max_label_length.
+	detailMetrics bool
+
+	// 不输出*_detail指标但限制输出行数和标签长度.
+	limits bool
+
+	// 抓取器特有的开关.
+	perSegment        bool
+	aggregateSegments bool
+
+	// 支持的阈值名称.
+	thresholds []string
+}
+
+/**
+* 函数：check
+* 功能：校验选项是否都被抓取器支持
+ */
+func (o ScraperOptions) check(supported supportedOptions) error {
+	if err := o.checkThresholds(supported.thresholds...); err != nil {
+		return err
+	}
+
+	if !supported.databases && !o.Databases.IsZero() {
+		return errors.New("databases filter is not supported")
+	}
+
+	if err := o.Databases.Validate(); err != nil {
+		return err
+	}
+
+	if !supported.tables && !o.Tables.IsZero() {
+		return errors.New("tables filter is not supported")
+	}
+
+	if err := o.Tables.Validate(); err != nil {
+		return err
+	}
+
+	if !supported.perSegment && o.PerSegment {
+		return errors.New("per_segment is not supported")
+	}
+
+	if !supported.aggregateSegments && o.AggregateSegments {
+		return errors.New("aggregate_segments is not supported")
+	}
+
+	if !supported.detailMetrics && o.DetailMetrics != nil {
+		return errors.New("detail_metrics is not supported")
+	}
+
+	if !supported.detailMetrics && !supported.limits && (o.MaxRows != 0 || o.MaxLabelLength != 0) {
+		return errors.New("max_rows and max_label_length are not supported")
+	}
+
+	if o.MaxRows < 0 || o.MaxLabelLength < 0 {
+		return errors.New("max_rows and max_label_length must not be negative")
+	}
+
+	if o.MaxLabelLength != 0 && o.MaxLabelLength <= len(truncatedSuffix) {
+		return fmt.Errorf("max_label_length must be greater than %d", len(truncatedSuffix))
+	}
+
+	return nil
+}
+
+/**
+* 函数：checkThresholds
+* 功能：检查配置的阈值名称是否都被抓取器支持
+ */
+func (o ScraperOptions) checkThresholds(allowed ...string) error {
+	unknown := make([]string, 0)
+	for name := range o.Thresholds {
+		found := false
+		for _, a := range allowed {
+			if name == a {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			unknown = append(unknown, name)
+		}
+	}
+
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	sort.Strings(unknown)
+
+	return fmt.Errorf("unknown thresholds %v, supported thresholds: %v", unknown, allowed)
+}
+
+/**
+* 函数：IsZero
+* 功能：判断过滤列表是否为空
+ */
+func (f DatabaseFilter) IsZero() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0 && !f.SkipDisallowedConnections
+}
+
+/**
+* 函数：Validate
+* 功能：校验过滤列表中的正则表达式
+ */
+func (f DatabaseFilter) Validate() error {
+	for _, pattern := range append(append([]string{}, f.Include...), f.Exclude...) {
+		if _, err := namePattern(pattern); err != nil {
+			return fmt.Errorf("invalid databases pattern %q: %v", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+/**
+* 函数：allowed
+* 功能：判断数据库是否需要抓取，allowConn为数据库的datallowconn
+ */
+func (f DatabaseFilter) allowed(dbname string, allowConn bool) bool {
+	if f.SkipDisallowedConnections && !allowConn {
+		return false
+	}
+
+	if matchName(f.Exclude, dbname) {
+		return false
+	}
+
+	return len(f.Include) == 0 || matchName(f.Include, dbname)
+}
+
+/**
+* 函数：IsZero
+* 功能：判断表过滤列表是否为空
+ */
+func (f TableFilter) IsZero() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+/**
+* 函数：Validate
+* 功能：校验表过滤列表中的正则表达式
+ */
+func (f TableFilter) Validate() error {
+	for _, pattern := range append(append([]string{}, f.Include...), f.Exclude...) {
+		if _, err := namePattern(pattern); err != nil {
+			return fmt.Errorf("invalid tables pattern %q: %v", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+/**
+* 函数：allowed
+* 功能：判断表是否需要抓取，Include为空时不抓取任何表
+ */
+func (f TableFilter) allowed(schemaName, tableName string) bool {
+	name := schemaName + "." + tableName
+
+	return !matchName(f.Exclude, name) && matchName(f.Include, name)
+}
+
+func matchName(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		re, err := namePattern(pattern)
+		if err == nil && re.MatchString(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/**
+* 函数：namePattern
+* 功能：编译需要匹配整个名称的正则表达式，编译结果会被缓存
+ */
+func namePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := namePatterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, err
+	}
+
+	namePatterns.Store(pattern, re)
+
+	return re, nil
+}
@@ -0,0 +1,145 @@
+package collector
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestDatabaseFilter(t *testing.T) {
+	cases := []struct {
+		name      string
+		filter    DatabaseFilter
+		dbname    string
+		allowConn bool
+		expected  bool
+	}{
+		{"empty filter", DatabaseFilter{}, "postgres", true, true},
+		{"empty filter disallowed connections", DatabaseFilter{}, "postgres", false, true},
+		{"include", DatabaseFilter{Include: []string{"dw_.*"}}, "dw_sales", true, true},
+		{"not included", DatabaseFilter{Include: []string{"dw_.*"}}, "postgres", true, false},
+		{"include matches the whole name", DatabaseFilter{Include: []string{"dw"}}, "dw_sales", true, false},
+		{"exclude", DatabaseFilter{Exclude: []string{"gpperfmon"}}, "gpperfmon", true, false},
+		{"not excluded", DatabaseFilter{Exclude: []string{"gpperfmon"}}, "postgres", true, true},
+		{"exclude wins over include", DatabaseFilter{Include: []string{"dw_.*"}, Exclude: []string{"dw_tmp"}}, "dw_tmp", true, false},
+		{"included and not excluded", DatabaseFilter{Include: []string{"dw_.*"}, Exclude: []string{"dw_tmp"}}, "dw_sales", true, true},
+		{"alternation", DatabaseFilter{Include: []string{"a|b"}}, "b", true, true},
+		{"skip disallowed connections", DatabaseFilter{SkipDisallowedConnections: true}, "postgres", false, false},
+		{"allowed connections", DatabaseFilter{SkipDisallowedConnections: true}, "postgres", true, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.filter.Validate(); err != nil {
+				t.Fatal(err)
+			}
+
+			if allowed := c.filter.allowed(c.dbname, c.allowConn); allowed != c.expected {
+				t.Errorf("allowed(%q, %v) = %v, expected %v", c.dbname, c.allowConn, allowed, c.expected)
+			}
+		})
+	}
+
+	if err := (DatabaseFilter{Exclude: []string{"("}}).Validate(); err == nil {
+		t.Errorf("expected error for an invalid pattern")
+	}
+}
+
+func TestScraperEnvDatabases(t *testing.T) {
+	f, db := newFakeDB()
+	f.on("from pg_database", []string{"datname", "datallowconn"},
+		[]driver.Value{"postgres", true},
+		[]driver.Value{"dw_sales", true},
+		[]driver.Value{"dw_tmp", true},
+		[]driver.Value{"dw_archive", false},
+	)
+
+	// 全局过滤和抓取器的过滤同时生效
+	env := &scraperEnv{
+		conns:          newConnManager(CollectorOptions{}),
+		databaseFilter: DatabaseFilter{Exclude: []string{"dw_tmp"}},
+	}
+
+	names, err := env.databases(context.Background(), db, DatabaseFilter{Include: []string{"dw_.*"}, SkipDisallowedConnections: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{"dw_sales"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("databases() = %v, expected %v", names, expected)
+	}
+}
+
+func TestPerDatabaseScrapersContinueOnError(t *testing.T) {
+	for _, scraper := range []Scraper{NewbloatScraper(), NewDataSkewScraper()} {
+		t.Run(scraper.Name(), func(t *testing.T) {
+			f, db := newFakeDB()
+			f.on("from pg_database", []string{"datname", "datallowconn"},
+				[]driver.Value{"postgres", true},
+				[]driver.Value{"dw_sales", true},
+			)
+
+			redactor, err := newRedactor(RedactionOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// 已关闭的连接池管理器对每个数据库都返回错误
+			conns := newConnManager(CollectorOptions{})
+			conns.close()
+
+			scraper.(baseHolder).setEnv(&scraperEnv{
+				conns:      conns,
+				details:    newDetailStore(),
+				redactor:   redactor,
+				redactions: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "redactions"}, []string{"scraper"}),
+			})
+
+			_, err = scrapeMetrics(t, scraper, db, 6)
+
+			var m multiError
+			if !errors.As(err, &m) || len(m) != 2 {
+				t.Errorf("Scrape() = %v, expected an error for each database", err)
+			}
+		})
+	}
+}
+
+func TestTableFilter(t *testing.T) {
+	cases := []struct {
+		name     string
+		filter   TableFilter
+		schema   string
+		table    string
+		expected bool
+	}{
+		{"empty filter", TableFilter{}, "public", "orders", false},
+		{"exclude only", TableFilter{Exclude: []string{"public\\.tmp_.*"}}, "public", "orders", false},
+		{"include", TableFilter{Include: []string{"public\\..*"}}, "public", "orders", true},
+		{"not included", TableFilter{Include: []string{"public\\..*"}}, "dw", "orders", false},
+		{"include matches the whole name", TableFilter{Include: []string{"public\\.order"}}, "public", "orders", false},
+		{"schema and table", TableFilter{Include: []string{"dw\\..*_fact"}}, "dw", "sales_fact", true},
+		{"exclude wins over include", TableFilter{Include: []string{"public\\..*"}, Exclude: []string{"public\\.tmp_.*"}}, "public", "tmp_orders", false},
+		{"included and not excluded", TableFilter{Include: []string{"public\\..*"}, Exclude: []string{"public\\.tmp_.*"}}, "public", "orders", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.filter.Validate(); err != nil {
+				t.Fatal(err)
+			}
+
+			if allowed := c.filter.allowed(c.schema, c.table); allowed != c.expected {
+				t.Errorf("allowed(%q, %q) = %v, expected %v", c.schema, c.table, allowed, c.expected)
+			}
+		})
+	}
+
+	if err := (TableFilter{Include: []string{"["}}).Validate(); err == nil {
+		t.Errorf("expected error for an invalid pattern")
+	}
+}
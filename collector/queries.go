@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"github.com/prometheus/client_golang/prometheus"
@@ -17,19 +18,19 @@ const (
 )
 
 var (
-	totalQueriesDesc = prometheus.NewDesc(
+	totalQueriesDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemCluster, "total_queries"),
 		"The total number of queries in hashdata Database at data collection time",
 		nil, nil,
 	)
 
-	runningQueriesDesc = prometheus.NewDesc(
+	runningQueriesDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemCluster, "running_queries"),
 		"The number of active queries running at data collection time",
 		nil, nil,
 	)
 
-	queuedQueriesDesc = prometheus.NewDesc(
+	queuedQueriesDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemCluster, "queued_queries"),
 		"The number of queries waiting in a resource group or resource queue",
 		nil, nil,
@@ -37,17 +38,19 @@ var (
 )
 
 func NewQueryScraper() Scraper {
-	return queriesScraper{}
+	return &queriesScraper{}
 }
 
-type queriesScraper struct{}
+type queriesScraper struct {
+	baseScraper
+}
 
 func (queriesScraper) Name() string {
 	return "queriesScraper"
 }
 
-func (queriesScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
-	rows, err := db.Query(queriesSql)
+func (queriesScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+	rows, err := db.QueryContext(ctx, queriesSql)
 	logger.Infof("Query Database: %s",queriesSql)
 
 	if err != nil {
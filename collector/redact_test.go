@@ -0,0 +1,75 @@
+package collector
+
+import "testing"
+
+func TestBuiltinRedactionRules(t *testing.T) {
+	r, err := newRedactor(RedactionOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		value    string
+		expected string
+		redacted bool
+	}{
+		{"plain query", "select * from t where id = 1", "select * from t where id = 1", false},
+		{"quoted password", "CREATE ROLE r LOGIN PASSWORD 'secret'", "CREATE ROLE r LOGIN PASSWORD '***'", true},
+		{"doubled quote", "alter role r encrypted password 'sec''ret' valid until 'infinity'", "alter role r encrypted password '***' valid until 'infinity'", true},
+		{"escape string", `CREATE ROLE r LOGIN PASSWORD E'sec\'ret'`, "CREATE ROLE r LOGIN PASSWORD '***'", true},
+		{"escape string with backslash", `alter user u password e'a\\b' login`, "alter user u password '***' login", true},
+		{"dollar quoted", "ALTER USER bob PASSWORD $$secret$$", "ALTER USER bob PASSWORD '***'", true},
+		{"tagged dollar quoted", "ALTER USER bob PASSWORD $pw$sec$ret$pw$;", "ALTER USER bob PASSWORD '***';", true},
+		{"conninfo", "host=h password=secret dbname=d", "host=h password=*** dbname=d", true},
+		{"pgpassword", "PGPASSWORD=secret psql", "PGPASSWORD=*** psql", true},
+		{"prefixed key", "aws_secret_access_key='abc' region=x", "aws_secret_access_key=*** region=x", true},
+		{"yaml token", `token: "abc"`, "token: ***", true},
+		{"api key", "apikey=abc&x=1", "apikey=***&x=1", true},
+		{"key with suffix", "update t set pwd_reset = true", "update t set pwd_reset = true", false},
+		{"column with suffix", "select 1 where password_changed = false", "select 1 where password_changed = false", false},
+		{"url", "postgres://u:secret@h:5432/db", "postgres://u:***@h:5432/db", true},
+		{"url without password", "postgres://u@h:5432/db", "postgres://u@h:5432/db", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			value, redacted := r.redact(c.value)
+			if value != c.expected || redacted != c.redacted {
+				t.Errorf("redact(%q) = %q, %v, expected %q, %v", c.value, value, redacted, c.expected, c.redacted)
+			}
+		})
+	}
+}
+
+func TestRedactionOptions(t *testing.T) {
+	cases := []struct {
+		name     string
+		opts     RedactionOptions
+		value    string
+		expected string
+	}{
+		{"custom rule", RedactionOptions{Rules: []RedactionRule{{Pattern: `[\w.+-]+@[\w-]+\.[\w.]+`}}}, "mail a.b@example.com", "mail ***"},
+		{"custom replacement", RedactionOptions{Rules: []RedactionRule{{Pattern: `(id_card\s*=\s*)\S+`, Replacement: "${1}#"}}}, "id_card = 123", "id_card = #"},
+		{"builtin disabled", RedactionOptions{DisableBuiltin: true}, "password=secret", "password=secret"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r, err := newRedactor(c.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if value, _ := r.redact(c.value); value != c.expected {
+				t.Errorf("redact(%q) = %q, expected %q", c.value, value, c.expected)
+			}
+		})
+	}
+
+	for _, rule := range []RedactionRule{{Pattern: ""}, {Pattern: "("}} {
+		if err := (RedactionOptions{Rules: []RedactionRule{rule}}).Validate(); err == nil {
+			t.Errorf("expected error for pattern %q", rule.Pattern)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	logger "github.com/prometheus/common/log"
+)
+
+/**
+ * 资源组抓取器：输出每个资源组运行和排队的事务数、排队时间、每个主机（可选每个segment）的CPU和内存使用，
+ * 以及gp_resgroup_config中配置的限制，gp_resource_manager不是group时不输出任何指标
+ */
+
+const (
+	resourceManagerSql = `show gp_resource_manager`
+
+	resGroupStatusSql = `
+	select rsgname, num_running, num_queueing, num_queued, num_executed,
+		coalesce(extract(epoch from total_queue_duration), 0)
+	from gp_toolkit.gp_resgroup_status;`
+
+	resGroupPerHostSql_v6 = `
+	select rsgname, hostname, cpu, memory_used, memory_available
+	from gp_toolkit.gp_resgroup_status_per_host;`
+
+	resGroupPerHostSql_v7 = `
+	select rsgname, hostname, cpu_usage, memory_usage, null::numeric
+	from gp_toolkit.gp_resgroup_status_per_host;`
+
+	resGroupPerSegmentSql_v6 = `
+	select rsgname, hostname, segment_id, cpu, memory_used, memory_available
+	from gp_toolkit.gp_resgroup_status_per_segment;`
+
+	resGroupPerSegmentSql_v7 = `
+	select rsgname, hostname, segment_id, cpu_usage, memory_usage, null::numeric
+	from gp_toolkit.gp_resgroup_status_per_segment;`
+
+	// 不同版本的配置列不同，按列名输出所有数值列.
+	resGroupConfigSql = `select * from gp_toolkit.gp_resgroup_config;`
+)
+
+var (
+	resGroupRunningDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "resgroup_running_transactions"),
+		"Number of transactions currently running in the resource group",
+		[]string{"rsgname"}, nil,
+	)
+
+	resGroupQueueingDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "resgroup_queueing_transactions"),
+		"Number of transactions currently waiting in the queue of the resource group",
+		[]string{"rsgname"}, nil,
+	)
+
+	resGroupQueuedDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "resgroup_queued_transactions_total"),
+		"Number of transactions queued in the resource group since the cluster started",
+		[]string{"rsgname"}, nil,
+	)
+
+	resGroupExecutedDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "resgroup_executed_transactions_total"),
+		"Number of transactions executed in the resource group since the cluster started",
+		[]string{"rsgname"}, nil,
+	)
+
+	resGroupQueueDurationDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "resgroup_queue_duration_seconds_total"),
+		"Total time transactions spent waiting in the queue of the resource group since the cluster started",
+		[]string{"rsgname"}, nil,
+	)
+
+	resGroupCpuDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "resgroup_cpu_usage_percent"),
+		"CPU usage of the resource group on the host",
+		[]string{"rsgname", "hostname"}, nil,
+	)
+
+	resGroupMemoryUsedDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "resgroup_memory_used_mb"),
+		"Memory used by the resource group on the host",
+		[]string{"rsgname", "hostname"}, nil,
+	)
+
+	resGroupMemoryAvailableDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "resgroup_memory_available_mb"),
+		"Memory still available to the resource group on the host",
+		[]string{"rsgname", "hostname"}, nil,
+	)
+
+	resGroupSegmentCpuDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "resgroup_segment_cpu_usage_percent"),
+		"CPU usage of the resource group on the segment",
+		[]string{"rsgname", "hostname", "segment_id"}, nil,
+	)
+
+	resGroupSegmentMemoryUsedDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "resgroup_segment_memory_used_mb"),
+		"Memory used by the resource group on the segment",
+		[]string{"rsgname", "hostname", "segment_id"}, nil,
+	)
+
+	resGroupSegmentMemoryAvailableDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "resgroup_segment_memory_available_mb"),
+		"Memory still available to the resource group on the segment",
+		[]string{"rsgname", "hostname", "segment_id"}, nil,
+	)
+
+	resGroupLimitDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "resgroup_limit"),
+		"Limit configured for the resource group in gp_resgroup_config, the limit label is the column name",
+		[]string{"rsgname", "limit"}, nil,
+	)
+)
+
+// gp_resgroup_config中不作为限制输出的列.
+var resGroupConfigSkipColumns = map[string]bool{
+	"groupid":   true,
+	"groupname": true,
+	"cpuset":    true,
+}
+
+func NewResourceGroupScraper() Scraper {
+	return &resourceGroupScraper{}
+}
+
+type resourceGroupScraper struct {
+	baseScraper
+}
+
+func (resourceGroupScraper) Name() string {
+	return "resource_group_scraper"
+}
+
+func (s *resourceGroupScraper) Configure(opts ScraperOptions) error {
+	if err := opts.check(supportedOptions{perSegment: true}); err != nil {
+		return err
+	}
+
+	s.opts = opts
+
+	return nil
+}
+
+func (s *resourceGroupScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+	manager, err := resourceManager(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasPrefix(manager, "group") {
+		logger.Infof("gp_resource_manager is %s, skip scraper %s", manager, s.Name())
+		return nil
+	}
+
+	errs := make([]error, 0)
+	errs = append(errs, scrapeResGroupStatus(ctx, db, ch))
+
+	// 每个主机和每个segment的视图从V6开始提供
+	if ver >= 6 {
+		errs = append(errs, scrapeResGroupPerHost(ctx, db, ch, ver))
+
+		if s.opts.PerSegment {
+			errs = append(errs, scrapeResGroupPerSegment(ctx, db, ch, ver))
+		}
+	}
+
+	errs = append(errs, scrapeResGroupConfig(ctx, db, ch))
+
+	return combineErr(errs...)
+}
+
+/**
+* 函数：resourceManager
+* 功能：返回gp_resource_manager的值，group表示资源组，queue表示资源队列，没有该参数的旧版本只支持资源队列
+ */
+func resourceManager(ctx context.Context, db *sql.DB) (string, error) {
+	var manager string
+	err := db.QueryRowContext(ctx, resourceManagerSql).Scan(&manager)
+	logger.Infof("Query Database: %s", resourceManagerSql)
+
+	if hasErrorCode(err, undefinedObject) {
+		return "queue", nil
+	}
+
+	return manager, err
+}
+
+func scrapeResGroupStatus(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx, resGroupStatusSql)
+	logger.Infof("Query Database: %s", resGroupStatusSql)
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var rsgname string
+		var running, queueing, queued, executed, queueDuration float64
+		if err = rows.Scan(&rsgname, &running, &queueing, &queued, &executed, &queueDuration); err != nil {
+			return err
+		}
+
+		ch <- prometheus.MustNewConstMetric(resGroupRunningDesc, prometheus.GaugeValue, running, rsgname)
+		ch <- prometheus.MustNewConstMetric(resGroupQueueingDesc, prometheus.GaugeValue, queueing, rsgname)
+		ch <- prometheus.MustNewConstMetric(resGroupQueuedDesc, prometheus.CounterValue, queued, rsgname)
+		ch <- prometheus.MustNewConstMetric(resGroupExecutedDesc, prometheus.CounterValue, executed, rsgname)
+		ch <- prometheus.MustNewConstMetric(resGroupQueueDurationDesc, prometheus.CounterValue, queueDuration, rsgname)
+	}
+
+	return rows.Err()
+}
+
+func scrapeResGroupPerHost(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+	perHostSql := resGroupPerHostSql_v6
+	if ver >= 7 {
+		perHostSql = resGroupPerHostSql_v7
+	}
+
+	rows, err := db.QueryContext(ctx, perHostSql)
+	logger.Infof("Query Database: %s", perHostSql)
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var rsgname, hostname string
+		var cpu, memoryUsed, memoryAvailable sql.NullFloat64
+		if err = rows.Scan(&rsgname, &hostname, &cpu, &memoryUsed, &memoryAvailable); err != nil {
+			return err
+		}
+
+		emitNullGauge(ch, resGroupCpuDesc, cpu, rsgname, hostname)
+		emitNullGauge(ch, resGroupMemoryUsedDesc, memoryUsed, rsgname, hostname)
+		emitNullGauge(ch, resGroupMemoryAvailableDesc, memoryAvailable, rsgname, hostname)
+	}
+
+	return rows.Err()
+}
+
+func scrapeResGroupPerSegment(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+	perSegmentSql := resGroupPerSegmentSql_v6
+	if ver >= 7 {
+		perSegmentSql = resGroupPerSegmentSql_v7
+	}
+
+	rows, err := db.QueryContext(ctx, perSegmentSql)
+	logger.Infof("Query Database: %s", perSegmentSql)
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var rsgname, hostname, segmentID string
+		var cpu, memoryUsed, memoryAvailable sql.NullFloat64
+		if err = rows.Scan(&rsgname, &hostname, &segmentID, &cpu, &memoryUsed, &memoryAvailable); err != nil {
+			return err
+		}
+
+		emitNullGauge(ch, resGroupSegmentCpuDesc, cpu, rsgname, hostname, segmentID)
+		emitNullGauge(ch, resGroupSegmentMemoryUsedDesc, memoryUsed, rsgname, hostname, segmentID)
+		emitNullGauge(ch, resGroupSegmentMemoryAvailableDesc, memoryAvailable, rsgname, hostname, segmentID)
+	}
+
+	return rows.Err()
+}
+
+/**
+* 函数：scrapeResGroupConfig
+* 功能：按列名输出gp_resgroup_config中的数值列，-1等负数表示未限制，不输出
+ */
+func scrapeResGroupConfig(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx, resGroupConfigSql)
+	logger.Infof("Query Database: %s", resGroupConfigSql)
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err = rows.Scan(dest...); err != nil {
+			return err
+		}
+
+		var rsgname string
+		limits := make(map[string]float64)
+
+		for i, column := range columns {
+			if column == "groupname" {
+				rsgname = values[i].String
+			}
+
+			if resGroupConfigSkipColumns[column] || !values[i].Valid {
+				continue
+			}
+
+			value, err := strconv.ParseFloat(strings.TrimSpace(values[i].String), 64)
+			if err != nil || value < 0 {
+				continue
+			}
+
+			limits[column] = value
+		}
+
+		for limit, value := range limits {
+			ch <- prometheus.MustNewConstMetric(resGroupLimitDesc, prometheus.GaugeValue, value, rsgname, limit)
+		}
+	}
+
+	return rows.Err()
+}
+
+/**
+* 函数：emitNullGauge
+* 功能：值不为NULL时输出gauge
+ */
+func emitNullGauge(ch chan<- prometheus.Metric, desc *prometheus.Desc, value sql.NullFloat64, labels ...string) {
+	if value.Valid {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value.Float64, labels...)
+	}
+}
@@ -0,0 +1,147 @@
+package collector
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+// 各版本资源组视图的结果.
+func newResourceGroupDB(manager string) (*fakeDB, *sql.DB) {
+	f, db := newFakeDB()
+
+	f.on("show gp_resource_manager", []string{"gp_resource_manager"}, []driver.Value{manager})
+	f.on("gp_resgroup_status_per_host", []string{"rsgname", "hostname", "cpu", "memory_used", "memory_available"},
+		[]driver.Value{"admin_group", "sdw1", 12.5, 100.0, nil})
+	f.on("gp_resgroup_status_per_segment", []string{"rsgname", "hostname", "segment_id", "cpu", "memory_used", "memory_available"},
+		[]driver.Value{"admin_group", "sdw1", "0", 6.0, 50.0, 10.0})
+	f.on("gp_resgroup_status", []string{"rsgname", "num_running", "num_queueing", "num_queued", "num_executed", "total_queue_duration"},
+		[]driver.Value{"admin_group", int64(1), int64(0), int64(3), int64(10), 1.5})
+	f.on("gp_resgroup_config", []string{"groupid", "groupname"})
+
+	return f, db
+}
+
+func TestResourceGroupScraperQueries(t *testing.T) {
+	cases := []struct {
+		name       string
+		manager    string
+		ver        int
+		perSegment bool
+		executed   []string
+		skipped    []string
+	}{
+		{
+			name:    "queue manager",
+			manager: "queue",
+			ver:     6,
+			skipped: []string{"gp_resgroup_status", "gp_resgroup_config"},
+		},
+		{
+			name:     "v5 has no per host view",
+			manager:  "group",
+			ver:      5,
+			executed: []string{"gp_resgroup_status;", "gp_resgroup_config"},
+			skipped:  []string{"gp_resgroup_status_per_host", "gp_resgroup_status_per_segment"},
+		},
+		{
+			name:     "v6 per host",
+			manager:  "group",
+			ver:      6,
+			executed: []string{"gp_resgroup_status;", "cpu, memory_used, memory_available", "gp_resgroup_config"},
+			skipped:  []string{"cpu_usage", "gp_resgroup_status_per_segment"},
+		},
+		{
+			name:       "v6 per segment",
+			manager:    "group",
+			ver:        6,
+			perSegment: true,
+			executed:   []string{"hostname, cpu, memory_used", "hostname, segment_id, cpu, memory_used"},
+			skipped:    []string{"cpu_usage"},
+		},
+		{
+			name:       "v7 per segment",
+			manager:    "group",
+			ver:        7,
+			perSegment: true,
+			executed:   []string{"hostname, cpu_usage, memory_usage", "hostname, segment_id, cpu_usage, memory_usage"},
+			skipped:    []string{"memory_available"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			f, db := newResourceGroupDB(c.manager)
+
+			s := NewResourceGroupScraper().(*resourceGroupScraper)
+			if err := s.Configure(ScraperOptions{PerSegment: c.perSegment}); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := scrapeMetrics(t, s, db, c.ver); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, query := range c.executed {
+				if f.executed(query) == 0 {
+					t.Errorf("query containing %q was not executed", query)
+				}
+			}
+
+			for _, query := range c.skipped {
+				if f.executed(query) != 0 {
+					t.Errorf("query containing %q was executed", query)
+				}
+			}
+		})
+	}
+}
+
+func TestResourceGroupScraperUndefinedManager(t *testing.T) {
+	f, db := newFakeDB()
+	f.fail("show gp_resource_manager", &pq.Error{Code: undefinedObject})
+
+	metrics, err := scrapeMetrics(t, NewResourceGroupScraper(), db, 4)
+	if err != nil || len(metrics) != 0 {
+		t.Errorf("Scrape() = %v, %v, expected no metrics for versions without resource groups", metrics, err)
+	}
+}
+
+func TestResourceGroupConfigLimits(t *testing.T) {
+	f, db := newFakeDB()
+	f.on("show gp_resource_manager", []string{"gp_resource_manager"}, []driver.Value{"group"})
+	f.on("gp_resgroup_status", []string{"rsgname", "num_running", "num_queueing", "num_queued", "num_executed", "total_queue_duration"})
+	f.on("gp_resgroup_config",
+		[]string{"groupid", "groupname", "concurrency", "cpu_rate_limit", "memory_limit", "memory_shared_quota", "memory_spill_ratio", "memory_auditor", "cpuset"},
+		[]driver.Value{int64(6437), "default_group", "20", "-1", nil, " 80 ", "0", "vmtracker", "-1"},
+		[]driver.Value{int64(6438), "admin_group", int64(10), "10", "10", "80", "0", "vmtracker", "0-3"},
+	)
+
+	metrics, err := scrapeMetrics(t, NewResourceGroupScraper(), db, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	limit := func(rsgname, limit string) string {
+		return `hashdata_server_resgroup_limit{limit="` + limit + `",rsgname="` + rsgname + `"}`
+	}
+
+	// 负数、NULL、非数值以及groupid、groupname、cpuset列不输出
+	expected := map[string]float64{
+		limit("default_group", "concurrency"):         20,
+		limit("default_group", "memory_shared_quota"): 80,
+		limit("default_group", "memory_spill_ratio"):  0,
+		limit("admin_group", "concurrency"):           10,
+		limit("admin_group", "cpu_rate_limit"):        10,
+		limit("admin_group", "memory_limit"):          10,
+		limit("admin_group", "memory_shared_quota"):   80,
+		limit("admin_group", "memory_spill_ratio"):    0,
+	}
+
+	if !reflect.DeepEqual(metrics, expected) {
+		t.Errorf("limits = %v, expected %v", metrics, expected)
+	}
+}
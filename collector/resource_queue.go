@@ -0,0 +1,150 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+	logger "github.com/prometheus/common/log"
+)
+
+/**
+ * 资源队列抓取器：输出每个资源队列正在执行和等待的语句数，以及语句数、代价和内存的使用量和限制，
+ * gp_resource_manager不是queue时不输出任何指标，与资源组抓取器按该参数自动二选一
+ */
+
+const (
+	resQueueStatusSql = `
+	select rsqname, rsqholders, rsqwaiters, rsqcountvalue, rsqcountlimit,
+		rsqcostvalue, rsqcostlimit, rsqmemoryvalue, rsqmemorylimit
+	from gp_toolkit.gp_resqueue_status;`
+
+	// 没有gp_toolkit.gp_resqueue_status的版本使用pg_resqueue_status，不包含内存.
+	pgResQueueStatusSql = `
+	select rsqname, rsqholders, rsqwaiters, rsqcountvalue, rsqcountlimit,
+		rsqcostvalue, rsqcostlimit, null::real, null::real
+	from pg_resqueue_status;`
+)
+
+var (
+	resQueueActiveDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "resqueue_active_statements"),
+		"Number of statements currently running in the resource queue",
+		[]string{"rsqname"}, nil,
+	)
+
+	resQueueWaitingDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "resqueue_waiting_statements"),
+		"Number of statements currently waiting in the resource queue",
+		[]string{"rsqname"}, nil,
+	)
+
+	resQueueCountDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "resqueue_statement_slots_used"),
+		"Number of active statement slots in use in the resource queue",
+		[]string{"rsqname"}, nil,
+	)
+
+	resQueueCountLimitDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "resqueue_statement_slots_limit"),
+		"Active statements limit of the resource queue",
+		[]string{"rsqname"}, nil,
+	)
+
+	resQueueCostDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "resqueue_cost_used"),
+		"Total planner cost of the statements running in the resource queue",
+		[]string{"rsqname"}, nil,
+	)
+
+	resQueueCostLimitDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "resqueue_cost_limit"),
+		"Planner cost limit of the resource queue",
+		[]string{"rsqname"}, nil,
+	)
+
+	resQueueMemoryDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "resqueue_memory_used_bytes"),
+		"Memory reserved by the statements running in the resource queue",
+		[]string{"rsqname"}, nil,
+	)
+
+	resQueueMemoryLimitDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "resqueue_memory_limit_bytes"),
+		"Memory limit of the resource queue",
+		[]string{"rsqname"}, nil,
+	)
+)
+
+func NewResourceQueueScraper() Scraper {
+	return &resourceQueueScraper{}
+}
+
+type resourceQueueScraper struct {
+	baseScraper
+}
+
+func (resourceQueueScraper) Name() string {
+	return "resource_queue_scraper"
+}
+
+func (s *resourceQueueScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+	manager, err := resourceManager(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	if manager != "queue" {
+		logger.Infof("gp_resource_manager is %s, skip scraper %s", manager, s.Name())
+		return nil
+	}
+
+	rows, err := db.QueryContext(ctx, resQueueStatusSql)
+	logger.Infof("Query Database: %s", resQueueStatusSql)
+
+	if hasErrorCode(err, undefinedTable) {
+		rows, err = db.QueryContext(ctx, pgResQueueStatusSql)
+		logger.Infof("Query Database: %s", pgResQueueStatusSql)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var rsqname string
+		var holders, waiters float64
+		var count, countLimit, cost, costLimit, memory, memoryLimit sql.NullFloat64
+		err = rows.Scan(&rsqname, &holders, &waiters, &count, &countLimit, &cost, &costLimit, &memory, &memoryLimit)
+
+		if err != nil {
+			return err
+		}
+
+		ch <- prometheus.MustNewConstMetric(resQueueActiveDesc, prometheus.GaugeValue, holders, rsqname)
+		ch <- prometheus.MustNewConstMetric(resQueueWaitingDesc, prometheus.GaugeValue, waiters, rsqname)
+
+		emitNullGauge(ch, resQueueCountDesc, count, rsqname)
+		emitNullGauge(ch, resQueueCostDesc, cost, rsqname)
+		emitNullGauge(ch, resQueueMemoryDesc, memory, rsqname)
+
+		// 限制为-1时表示不限制，不输出
+		emitLimit(ch, resQueueCountLimitDesc, countLimit, rsqname)
+		emitLimit(ch, resQueueCostLimitDesc, costLimit, rsqname)
+		emitLimit(ch, resQueueMemoryLimitDesc, memoryLimit, rsqname)
+	}
+
+	return rows.Err()
+}
+
+/**
+* 函数：emitLimit
+* 功能：限制不为NULL且不小于0时输出gauge
+ */
+func emitLimit(ch chan<- prometheus.Metric, desc *prometheus.Desc, value sql.NullFloat64, labels ...string) {
+	if value.Valid && value.Float64 >= 0 {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value.Float64, labels...)
+	}
+}
@@ -0,0 +1,117 @@
+package collector
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+var resQueueColumns = []string{"rsqname", "rsqholders", "rsqwaiters", "rsqcountvalue", "rsqcountlimit",
+	"rsqcostvalue", "rsqcostlimit", "rsqmemoryvalue", "rsqmemorylimit"}
+
+func TestResourceQueueScraperQueries(t *testing.T) {
+	cases := []struct {
+		name     string
+		manager  string
+		toolkit  bool
+		executed []string
+		skipped  []string
+		metrics  int
+	}{
+		{
+			name:     "group manager",
+			manager:  "group",
+			executed: []string{"show gp_resource_manager"},
+			skipped:  []string{"resqueue_status"},
+		},
+		{
+			name:     "gp_toolkit view",
+			manager:  "queue",
+			toolkit:  true,
+			executed: []string{"gp_toolkit.gp_resqueue_status"},
+			skipped:  []string{"from pg_resqueue_status"},
+			metrics:  6,
+		},
+		{
+			name:     "fall back to pg_resqueue_status",
+			manager:  "",
+			executed: []string{"gp_toolkit.gp_resqueue_status", "from pg_resqueue_status"},
+			metrics:  5,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			f, db := newFakeDB()
+
+			// 没有gp_resource_manager参数的旧版本只支持资源队列
+			if c.manager == "" {
+				f.fail("show gp_resource_manager", &pq.Error{Code: undefinedObject})
+			} else {
+				f.on("show gp_resource_manager", []string{"gp_resource_manager"}, []driver.Value{c.manager})
+			}
+
+			if c.toolkit {
+				f.on("gp_toolkit.gp_resqueue_status", resQueueColumns,
+					[]driver.Value{"pg_default", int64(2), int64(1), 2.0, 20.0, 100.0, -1.0, 1024.0, -1.0})
+			} else {
+				f.fail("gp_toolkit.gp_resqueue_status", &pq.Error{Code: undefinedTable})
+			}
+
+			f.on("from pg_resqueue_status", resQueueColumns,
+				[]driver.Value{"pg_default", int64(2), int64(1), 2.0, 20.0, 100.0, -1.0, nil, nil})
+
+			metrics, err := scrapeMetrics(t, NewResourceQueueScraper(), db, 5)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, query := range c.executed {
+				if f.executed(query) == 0 {
+					t.Errorf("query containing %q was not executed", query)
+				}
+			}
+
+			for _, query := range c.skipped {
+				if f.executed(query) != 0 {
+					t.Errorf("query containing %q was executed", query)
+				}
+			}
+
+			// 限制为-1以及值为NULL的指标不输出
+			if len(metrics) != c.metrics {
+				t.Errorf("got %d metrics, expected %d: %v", len(metrics), c.metrics, metrics)
+			}
+		})
+	}
+}
+
+func TestResourceQueueScraperLimits(t *testing.T) {
+	f, db := newFakeDB()
+	f.on("show gp_resource_manager", []string{"gp_resource_manager"}, []driver.Value{"queue"})
+	f.on("gp_toolkit.gp_resqueue_status", resQueueColumns,
+		[]driver.Value{"pg_default", int64(2), int64(1), 2.0, 20.0, 100.0, -1.0, 1024.0, 4096.0})
+
+	metrics, err := scrapeMetrics(t, NewResourceQueueScraper(), db, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const labels = `{rsqname="pg_default"}`
+
+	expected := map[string]float64{
+		"hashdata_server_resqueue_active_statements" + labels:     2,
+		"hashdata_server_resqueue_waiting_statements" + labels:    1,
+		"hashdata_server_resqueue_statement_slots_used" + labels:  2,
+		"hashdata_server_resqueue_statement_slots_limit" + labels: 20,
+		"hashdata_server_resqueue_cost_used" + labels:             100,
+		"hashdata_server_resqueue_memory_used_bytes" + labels:     1024,
+		"hashdata_server_resqueue_memory_limit_bytes" + labels:    4096,
+	}
+
+	if !reflect.DeepEqual(metrics, expected) {
+		t.Errorf("metrics = %v, expected %v", metrics, expected)
+	}
+}
@@ -0,0 +1,68 @@
+package collector
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+/**
+ * 只读模式：所有连接以只读事务、较短的语句超时以及固定的application_name建立，
+ * 需要在数据库中执行DDL或DML的抓取器不能启用
+ */
+
+// 只读模式下连接使用的application_name.
+const readOnlyApplicationName = "hdw_exporter_readonly"
+
+// 连接的安全选项.
+type SafetyOptions struct {
+	// 为true时所有连接使用default_transaction_read_only=on.
+	ReadOnly bool
+
+	// 只读模式下连接的statement_timeout，为0时不设置.
+	StatementTimeout time.Duration
+}
+
+// 需要在数据库中执行DDL或DML的抓取器.
+type writingScraper interface {
+	writesDatabase()
+}
+
+/**
+* 函数：WritesDatabase
+* 功能：判断抓取器是否需要在数据库中执行写操作，这些抓取器在只读模式下不能启用
+ */
+func WritesDatabase(scraper Scraper) bool {
+	_, ok := scraper.(writingScraper)
+
+	return ok
+}
+
+/**
+* 函数：apply
+* 功能：只读模式下在连接参数中追加只读事务、语句超时和application_name参数
+ */
+func (o SafetyOptions) apply(params map[string]string) {
+	if !o.ReadOnly {
+		return
+	}
+
+	params["default_transaction_read_only"] = "on"
+	params["application_name"] = readOnlyApplicationName
+
+	if o.StatementTimeout > 0 {
+		params["statement_timeout"] = strconv.FormatInt(o.StatementTimeout.Milliseconds(), 10)
+	}
+}
+
+/**
+* 函数：String
+* 功能：用于日志输出
+ */
+func (o SafetyOptions) String() string {
+	if !o.ReadOnly {
+		return "read-write"
+	}
+
+	return fmt.Sprintf("read-only, statement_timeout=%v", o.StatementTimeout)
+}
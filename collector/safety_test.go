@@ -0,0 +1,76 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWritesDatabase(t *testing.T) {
+	for _, name := range ScraperNames() {
+		scraper, _ := NewScraper(name)
+
+		expected := name == "DataSkewScraper"
+		if writes := WritesDatabase(scraper); writes != expected {
+			t.Errorf("WritesDatabase(%s) = %v, expected %v", name, writes, expected)
+		}
+	}
+}
+
+/**
+* 函数：safetyParams
+* 功能：返回应用安全选项后的连接参数
+ */
+func safetyParams(t *testing.T, dataSourceName string, safety SafetyOptions) map[string]string {
+	params, err := CollectorOptions{DataSourceName: dataSourceName, Safety: safety}.connectionParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return params
+}
+
+func TestSafetyOptions(t *testing.T) {
+	cases := []struct {
+		name     string
+		dsn      string
+		safety   SafetyOptions
+		expected map[string]string
+	}{
+		{
+			name:     "read-write",
+			dsn:      "host=mdw application_name=psql",
+			safety:   SafetyOptions{StatementTimeout: time.Minute},
+			expected: map[string]string{"host": "mdw", "application_name": "psql"},
+		},
+		{
+			name:   "read-only",
+			dsn:    "host=mdw",
+			safety: SafetyOptions{ReadOnly: true},
+			expected: map[string]string{
+				"host":                          "mdw",
+				"default_transaction_read_only": "on",
+				"application_name":              readOnlyApplicationName,
+			},
+		},
+		{
+			name:   "read-only with statement timeout",
+			dsn:    "host=mdw application_name=psql statement_timeout=0",
+			safety: SafetyOptions{ReadOnly: true, StatementTimeout: 1500 * time.Millisecond},
+			expected: map[string]string{
+				"host":                          "mdw",
+				"default_transaction_read_only": "on",
+				"application_name":              readOnlyApplicationName,
+				"statement_timeout":             "1500",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if params := safetyParams(t, c.dsn, c.safety); !reflect.DeepEqual(params, c.expected) {
+				t.Errorf("connection parameters = %v, expected %v", params, c.expected)
+			}
+		})
+	}
+}
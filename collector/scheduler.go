@@ -0,0 +1,185 @@
+package collector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	logger "github.com/prometheus/common/log"
+)
+
+/**
+ * 后台抓取：配置了interval的抓取器按各自的间隔在后台运行，
+ * 每次抓取时直接输出缓存的最近一次结果，不再随Prometheus的请求运行
+ */
+
+var (
+	scraperLastSuccessDesc = newDesc(
+		prometheus.BuildFQName(namespace, subsystemExporter, "scraper_last_success_timestamp_seconds"),
+		"Timestamp of the last successful run of a background scraper",
+		[]string{"scraper"}, nil,
+	)
+
+	scraperCacheAgeDesc = newDesc(
+		prometheus.BuildFQName(namespace, subsystemExporter, "scraper_cache_age_seconds"),
+		"Age of the cached metrics served for a background scraper",
+		[]string{"scraper"}, nil,
+	)
+)
+
+// 后台运行的抓取器及其缓存的结果.
+type scheduledScraper struct {
+	scraper  Scraper
+	interval time.Duration
+
+	mu          sync.RWMutex
+	metrics     []prometheus.Metric
+	updated     time.Time
+	lastSuccess time.Time
+}
+
+// 采集器的后台抓取调度器，重新加载配置时整体替换.
+type scheduler struct {
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	scrapers []*scheduledScraper
+}
+
+/**
+* 函数：newScheduler
+* 功能：为每个抓取器启动后台goroutine，启动后立即运行一次，之后按间隔运行
+ */
+func newScheduler(c *HdwCollector, scrapers []Scraper) *scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s := &scheduler{cancel: cancel}
+
+	for _, scraper := range scrapers {
+		ss := &scheduledScraper{
+			scraper:  scraper,
+			interval: scraperOptions(scraper).Interval,
+		}
+
+		s.scrapers = append(s.scrapers, ss)
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			ss.loop(ctx, c)
+		}()
+	}
+
+	return s
+}
+
+/**
+* 函数：stop
+* 功能：停止所有后台抓取，正在执行的查询会被取消
+ */
+func (s *scheduler) stop() {
+	s.cancel()
+}
+
+/**
+* 函数：wait
+* 功能：等待所有后台抓取结束，后台抓取获取连接时需要采集器的锁，因此调用时不能持有该锁
+ */
+func (s *scheduler) wait() {
+	s.wg.Wait()
+}
+
+/**
+* 函数：collect
+* 功能：输出所有后台抓取器缓存的指标，以及最近成功时间和缓存时长
+ */
+func (s *scheduler) collect(ch chan<- prometheus.Metric) {
+	now := time.Now()
+
+	for _, ss := range s.scrapers {
+		ss.mu.RLock()
+
+		for _, m := range ss.metrics {
+			ch <- m
+		}
+
+		var lastSuccess float64
+		if !ss.lastSuccess.IsZero() {
+			lastSuccess = float64(ss.lastSuccess.UnixNano()) / 1e9
+		}
+		ch <- prometheus.MustNewConstMetric(scraperLastSuccessDesc, prometheus.GaugeValue, lastSuccess, ss.scraper.Name())
+
+		if !ss.updated.IsZero() {
+			ch <- prometheus.MustNewConstMetric(scraperCacheAgeDesc, prometheus.GaugeValue, now.Sub(ss.updated).Seconds(), ss.scraper.Name())
+		}
+
+		ss.mu.RUnlock()
+	}
+}
+
+func (ss *scheduledScraper) loop(ctx context.Context, c *HdwCollector) {
+	ticker := time.NewTicker(ss.interval)
+	defer ticker.Stop()
+
+	for {
+		ss.run(ctx, c)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+/**
+* 函数：run
+* 功能：运行一次抓取器并更新缓存，未配置timeout时以interval作为超时时间，避免两次运行重叠
+ */
+func (ss *scheduledScraper) run(ctx context.Context, c *HdwCollector) {
+	if scraperOptions(ss.scraper).Timeout == 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ss.interval)
+		defer cancel()
+	}
+
+	db, ver, err := c.connection(ctx)
+	if err != nil {
+		logger.Errorf("background scraper:%s check database connection failed, error:%v", ss.scraper.Name(), err)
+		c.metrics.scraperSuccess.WithLabelValues(ss.scraper.Name()).Set(0)
+		c.metrics.scraperErrors.WithLabelValues(ss.scraper.Name(), classifyError(err)).Inc()
+		return
+	}
+
+	defer db.release()
+
+	ch := make(chan prometheus.Metric)
+	metrics := make([]prometheus.Metric, 0)
+	done := make(chan struct{})
+
+	go func() {
+		for m := range ch {
+			metrics = append(metrics, m)
+		}
+		close(done)
+	}()
+
+	elapsed, err := c.runScraper(ctx, ss.scraper, db.db, ver, ch)
+	close(ch)
+	<-done
+
+	logger.Infof("background scraper:%s finished, elapsed:%dms", ss.scraper.Name(), elapsed.Milliseconds())
+
+	if err != nil && len(metrics) == 0 {
+		return
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	ss.metrics = metrics
+	ss.updated = time.Now()
+	if err == nil {
+		ss.lastSuccess = ss.updated
+	}
+}
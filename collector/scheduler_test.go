@@ -0,0 +1,243 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// 后台运行的测试抓取器，每次运行输出当前的运行次数.
+type countingScraper struct {
+	baseScraper
+
+	name string
+	desc *prometheus.Desc
+
+	mu          sync.Mutex
+	runs        int
+	hasDeadline bool
+}
+
+func newCountingScraper(name string, interval time.Duration) *countingScraper {
+	s := &countingScraper{
+		name: name,
+		desc: prometheus.NewDesc("test_"+name+"_runs", "Number of runs", nil, nil),
+	}
+	s.opts = ScraperOptions{Interval: interval}
+
+	return s
+}
+
+func (s *countingScraper) Name() string {
+	return s.name
+}
+
+func (s *countingScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+	s.mu.Lock()
+	s.runs++
+	runs := s.runs
+	_, s.hasDeadline = ctx.Deadline()
+	s.mu.Unlock()
+
+	ch <- prometheus.MustNewConstMetric(s.desc, prometheus.GaugeValue, float64(runs))
+
+	return nil
+}
+
+func (s *countingScraper) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.runs
+}
+
+// 运行到被取消后才获取按数据库缓存的连接池的测试抓取器.
+type blockingScraper struct {
+	baseScraper
+
+	started chan struct{}
+
+	mu       sync.Mutex
+	finished bool
+	err      error
+}
+
+func (s *blockingScraper) Name() string {
+	return "blocking"
+}
+
+func (s *blockingScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+	close(s.started)
+	<-ctx.Done()
+
+	// 模拟取消后仍在使用连接池的抓取器
+	time.Sleep(20 * time.Millisecond)
+	_, err := s.env.conns.database("postgres")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.finished = true
+	s.err = err
+
+	return err
+}
+
+/**
+* 函数：newTestCollector
+* 功能：创建使用内存数据库的采集器，数据库的版本为6
+ */
+func newTestCollector(t *testing.T, scrapers ...Scraper) *HdwCollector {
+	f, db := newFakeDB()
+	f.on("regexp_matches", []string{"version"}, []driver.Value{int64(6)})
+
+	c := NewCollector(CollectorOptions{}, nil)
+
+	c.mu.Lock()
+	c.db = &sharedDB{db: db}
+	c.mu.Unlock()
+
+	c.Reload(CollectorOptions{}, scrapers)
+	t.Cleanup(c.Close)
+
+	return c
+}
+
+/**
+* 函数：cachedMetrics
+* 功能：运行一次采集，返回其中desc为指定值的指标
+ */
+func cachedMetrics(c *HdwCollector, desc *prometheus.Desc) metricsCollector {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+
+	metrics := make(metricsCollector, 0)
+	for m := range ch {
+		if m.Desc() == desc {
+			metrics = append(metrics, m)
+		}
+	}
+
+	return metrics
+}
+
+/**
+* 函数：waitRuns
+* 功能：等待抓取器至少运行n次
+ */
+func waitRuns(t *testing.T, s *countingScraper, n int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for s.count() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("scraper %s ran %d times, expected at least %d", s.name, s.count(), n)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSchedulerServesCachedResults(t *testing.T) {
+	s := newCountingScraper("cached", time.Hour)
+	c := newTestCollector(t, s)
+
+	waitRuns(t, s, 1)
+
+	for i := 0; i < 3; i++ {
+		metrics := cachedMetrics(c, s.desc)
+		if len(metrics) != 1 {
+			t.Fatalf("collect #%d returned %d cached metrics, expected 1", i, len(metrics))
+		}
+
+		if v := testutil.ToFloat64(metrics); v != 1 {
+			t.Errorf("collect #%d returned run %v, expected the cached run 1", i, v)
+		}
+	}
+
+	if runs := s.count(); runs != 1 {
+		t.Errorf("scraper ran %d times, expected 1 within its interval", runs)
+	}
+
+	if lastSuccess := cachedMetrics(c, scraperLastSuccessDesc); len(lastSuccess) != 1 || testutil.ToFloat64(lastSuccess) == 0 {
+		t.Errorf("expected a last success timestamp for the background scraper")
+	}
+}
+
+func TestSchedulerInterval(t *testing.T) {
+	s := newCountingScraper("interval", 10*time.Millisecond)
+	c := newTestCollector(t, s)
+
+	waitRuns(t, s, 3)
+
+	if v := testutil.ToFloat64(cachedMetrics(c, s.desc)); v < 2 {
+		t.Errorf("cached run = %v, expected a refreshed result", v)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.hasDeadline {
+		t.Errorf("background scraper without timeout should be limited by its interval")
+	}
+}
+
+func TestSchedulerReload(t *testing.T) {
+	old := newCountingScraper("old", 10*time.Millisecond)
+	c := newTestCollector(t, old)
+
+	waitRuns(t, old, 2)
+
+	replacement := newCountingScraper("replacement", 10*time.Millisecond)
+	c.Reload(CollectorOptions{}, []Scraper{replacement})
+
+	waitRuns(t, replacement, 2)
+
+	stopped := old.count()
+	time.Sleep(50 * time.Millisecond)
+
+	if runs := old.count(); runs != stopped {
+		t.Errorf("replaced scraper kept running after reload: %d runs, expected %d", runs, stopped)
+	}
+
+	if metrics := cachedMetrics(c, old.desc); len(metrics) != 0 {
+		t.Errorf("results of the replaced scraper are still served")
+	}
+
+	if metrics := cachedMetrics(c, replacement.desc); len(metrics) != 1 {
+		t.Errorf("results of the new scraper are not served")
+	}
+}
+
+func TestSchedulerReloadWaitsForBackgroundScrapers(t *testing.T) {
+	s := &blockingScraper{started: make(chan struct{})}
+	s.opts = ScraperOptions{Interval: time.Hour}
+
+	c := newTestCollector(t, s)
+	<-s.started
+
+	old := c.conns
+	c.Reload(CollectorOptions{DataSourceName: "host=sdw"}, nil)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.finished {
+		t.Fatalf("reload returned before the background scraper finished")
+	}
+
+	if s.err != nil {
+		t.Errorf("background scraper got %v, the old connection pools were closed while it was running", s.err)
+	}
+
+	if _, err := old.database("postgres"); err != errConnManagerClosed {
+		t.Errorf("old connection manager is not closed after reload: %v", err)
+	}
+}
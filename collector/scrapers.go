@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"database/sql"
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -15,5 +16,205 @@ type Scraper interface {
 	Name() string
 
 	// 从数据库连接中获取数据信息，并发送到数据类型为prometheus metric的通道里.
-	Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver int) error
+	// ctx被取消或超时时，正在执行的查询会被取消，抓取器应尽快返回.
+	Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error
+}
+
+// 内置指标的描述，由newDesc在包初始化时记录，注册采集器时用于检查probe目标的标签是否与指标自身的标签冲突.
+var builtinDescs []*prometheus.Desc
+
+/**
+* 函数：newDesc
+* 功能：创建内置指标的描述并记录，只在包初始化时调用
+ */
+func newDesc(fqName, help string, variableLabels []string, constLabels prometheus.Labels) *prometheus.Desc {
+	desc := prometheus.NewDesc(fqName, help, variableLabels, constLabels)
+	builtinDescs = append(builtinDescs, desc)
+
+	return desc
+}
+
+// 指标随配置变化的抓取器（自定义查询）输出自身的指标描述.
+type describer interface {
+	Describe(ch chan<- *prometheus.Desc)
+}
+
+/**
+* 函数：describeScrapers
+* 功能：输出所有内置指标以及scrapers中自定义查询的指标描述
+ */
+func describeScrapers(ch chan<- *prometheus.Desc, scrapers []Scraper) {
+	for _, desc := range builtinDescs {
+		ch <- desc
+	}
+
+	for _, scraper := range scrapers {
+		if d, ok := scraper.(describer); ok {
+			d.Describe(ch)
+		}
+	}
+}
+
+// 只输出指标描述、不执行抓取的Collector.
+type scraperDescriber struct {
+	metrics  *ExporterMetrics
+	scrapers []Scraper
+}
+
+/**
+* 函数：NewScraperDescriber
+* 功能：返回描述采集器以及抓取器所有指标的Collector，用于在不连接数据库的情况下检查注册时的标签冲突
+ */
+func NewScraperDescriber(scrapers []Scraper) prometheus.Collector {
+	return &scraperDescriber{metrics: NewMetrics(), scrapers: scrapers}
+}
+
+func (d *scraperDescriber) Describe(ch chan<- *prometheus.Desc) {
+	d.metrics.describe(ch)
+	describeScrapers(ch, d.scrapers)
+}
+
+func (d *scraperDescriber) Collect(ch chan<- prometheus.Metric) {
+}
+
+// 所有可用的抓取器，enabled为未在配置文件中出现时的默认启用状态.
+var scraperRegistry = []struct {
+	factory func() Scraper
+	enabled bool
+}{
+	{NewClusterStateScraper, true},
+	{NewSegmentScraper, true},
+	{NewDatabaseSizeScraper, true},
+	{NewLocksScraper, true},
+	{NewConnectionsScraper, true},
+	{NewMaxConnScraper, true},
+	{NewConnDetailScraper, true},
+	{NewUsersScraper, false},
+	{NewBgWriterStateScraper, false},
+
+	{NewSystemScraper, false},
+	{NewQueryScraper, false},
+	{NewDynamicMemoryScraper, false},
+	{NewDiskScraper, false},
+
+	{NewActivityScraper, true},
+	{NewSessionMemoryScraper, true},
+	{NewbloatScraper, true},
+	{NewDataSkewScraper, false},
+	{NewTableSkewScraper, true},
+	{NewMasterLogScraper, true},
+	{NewResourceGroupScraper, true},
+	{NewResourceQueueScraper, true},
+	{NewStatDatabaseScraper, true},
+	{NewTableStatsScraper, false},
+	{NewAOTableScraper, false},
+}
+
+/**
+* 函数：ScraperNames
+* 功能：返回所有可用抓取器的名称
+ */
+func ScraperNames() []string {
+	names := make([]string, 0, len(scraperRegistry))
+	for _, entry := range scraperRegistry {
+		names = append(names, entry.factory().Name())
+	}
+
+	return names
+}
+
+/**
+* 函数：NewScraper
+* 功能：根据名称创建抓取器，第二个返回值为默认启用状态，名称不存在时返回nil
+ */
+func NewScraper(name string) (Scraper, bool) {
+	for _, entry := range scraperRegistry {
+		scraper := entry.factory()
+		if scraper.Name() == name {
+			return scraper, entry.enabled
+		}
+	}
+
+	return nil, false
+}
+
+// 抓取器运行时依赖的采集器信息，由HdwCollector在设置抓取器时注入.
+type scraperEnv struct {
+	// 按数据库缓存的连接池，用于逐个数据库查询的抓取器.
+	conns *connManager
+
+	// 全局的数据库过滤列表，与抓取器的过滤列表同时生效.
+	databaseFilter DatabaseFilter
+
+	// 采集器的明细数据缓存.
+	details *DetailStore
+
+	// 被高基数保护丢弃的*_detail指标行数.
+	seriesDropped *prometheus.CounterVec
+
+	// 标签值和明细数据的脱敏规则，以及被脱敏的次数.
+	redactor   *redactor
+	redactions *prometheus.CounterVec
+}
+
+/**
+* 函数：publish
+* 功能：对明细行的所有字段脱敏后发布到明细数据缓存
+ */
+func (e *scraperEnv) publish(scraper, dataset string, rows []DetailRow) {
+	redactions := e.redactions.WithLabelValues(scraper)
+
+	for _, row := range rows {
+		for field, value := range row {
+			if redacted, ok := e.redactor.redact(value); ok {
+				row[field] = redacted
+				redactions.Inc()
+			}
+		}
+	}
+
+	e.details.publish(dataset, rows)
+}
+
+// 嵌入了baseScraper的抓取器.
+type baseHolder interface {
+	setEnv(env *scraperEnv)
+	options() ScraperOptions
+}
+
+// 所有抓取器的公共部分，保存配置选项以及采集器注入的信息.
+type baseScraper struct {
+	env  *scraperEnv
+	opts ScraperOptions
+}
+
+func (b *baseScraper) setEnv(env *scraperEnv) {
+	b.env = env
+}
+
+func (b *baseScraper) options() ScraperOptions {
+	return b.opts
+}
+
+// 只支持通用选项的抓取器使用的默认实现，支持阈值或数据库过滤的抓取器需要自行实现.
+func (b *baseScraper) Configure(opts ScraperOptions) error {
+	if err := opts.check(supportedOptions{}); err != nil {
+		return err
+	}
+
+	b.opts = opts
+
+	return nil
+}
+
+/**
+* 函数：scraperOptions
+* 功能：返回抓取器的配置选项，未嵌入baseScraper的抓取器返回默认选项
+ */
+func scraperOptions(scraper Scraper) ScraperOptions {
+	if bh, ok := scraper.(baseHolder); ok {
+		return bh.options()
+	}
+
+	return ScraperOptions{}
 }
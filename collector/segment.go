@@ -22,25 +22,25 @@ const (
 )
 
 var (
-	statusDesc = prometheus.NewDesc(
+	statusDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "segment_status"),
 		"UP(1) if the segment is running, DOWN(0) if the segment has failed or is unreachable",
 		[]string{"hostname", "address", "dbid", "content", "preferred_role", "port", "data_dir"}, nil,
 	)
 
-	roleDesc = prometheus.NewDesc(
+	roleDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "segment_role"),
 		"The segment's current role, either primary or mirror",
 		[]string{"hostname", "address", "dbid", "content", "preferred_role", "port", "data_dir"}, nil,
 	)
 
-	modeDesc = prometheus.NewDesc(
+	modeDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "segment_mode"),
 		"The replication status for the segment",
 		[]string{"hostname", "address", "dbid", "content", "preferred_role", "port", "data_dir"}, nil,
 	)
 
-	segmentDiskFreeSizeDesc = prometheus.NewDesc(
+	segmentDiskFreeSizeDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "segment_disk_free_gb_size"), //指标的名称
 		"Total GB size of each segment node free size of disk in the file system",     //帮助信息，显示在指标的上面作为注释
 		[]string{"hostname"}, //定义的label名称数组
@@ -49,25 +49,26 @@ var (
 )
 
 func NewSegmentScraper() Scraper {
-	return segmentScraper{}
+	return &segmentScraper{}
 }
 
-type segmentScraper struct{}
+type segmentScraper struct {
+	baseScraper
+}
 
 func (segmentScraper) Name() string {
 	return "segment_scraper"
 }
 
-func (segmentScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
-	errU := scrapeSegmentConfig(db, ch, ver)
-	errC := scrapeSegmentDiskFree(db, ch)
+func (s *segmentScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+	errU := scrapeSegmentConfig(ctx, db, ch, ver, s.opts.timeout(time.Second*2))
+	errC := scrapeSegmentDiskFree(ctx, db, ch, s.opts.timeout(time.Second*10))
 
 	return combineErr(errC, errU)
 }
 
-func scrapeSegmentConfig(db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
-	ctx := context.Background()
-	ctx, cancel := context.WithTimeout(ctx, time.Second*2)
+func scrapeSegmentConfig(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 
 	defer cancel()
 
@@ -106,9 +107,8 @@ func scrapeSegmentConfig(db *sql.DB, ch chan<- prometheus.Metric, ver int) error
 	return combineErr(errs...)
 }
 
-func scrapeSegmentDiskFree(db *sql.DB, ch chan<- prometheus.Metric) error {
-	ctx := context.Background()
-	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+func scrapeSegmentDiskFree(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 
 	defer cancel()
 
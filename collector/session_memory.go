@@ -1,8 +1,11 @@
 package collector
 
 import (
+	"context"
 	"database/sql"
+	"strconv"
 	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	logger "github.com/prometheus/common/log"
 )
@@ -14,20 +17,29 @@ const (
 		on a.sess_id = b.sess_id
 		where b.pid <> pg_backend_pid()
 		and b.datname is not null
-		group by b.pid,b.sess_id,b.datname,b.usename, b.query`
+		group by b.pid,b.sess_id,b.datname,b.usename, b.query
+		order by vmem_total desc`
 	sessionMemorySql_v5 = `
 		select now(),b.procpid,a.sess_id,a.datname,a.usename, max(vmem_mb) as vmem_max_seg,round(avg(vmem_mb)) as vmem_avg,sum(vmem_mb) as vmem_total, a.current_query
 		from session_state.session_level_memory_consumption a join pg_stat_activity b
 		on a.sess_id = b.sess_id
 		where b.procpid <> pg_backend_pid()
-		group by b.procpid,a.sess_id,a.datname,a.usename, a.current_query`
+		group by b.procpid,a.sess_id,a.datname,a.usename, a.current_query
+		order by vmem_total desc`
 )
 
 var (
-	sessionMemoryDesc = prometheus.NewDesc(
+	sessionMemoryDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemServer, "session_memory_detail"),
 		"Sessions memory usage detail for all running sessions",
-		[]string{"pid", "sess_id", "datname", "usename", "vmem_max_seg", "vmem_avg", "vmem_total", "query"},
+		[]string{"pid", "sess_id", "datname", "usename", "vmem_max_seg", "vmem_avg", "vmem_total", "query", "query_hash"},
+		nil,
+	)
+
+	sessionMemoryTotalDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "session_memory_total_mb"),
+		"Total memory in MB used by running sessions of each database",
+		[]string{"datname"},
 		nil,
 	)
 )
@@ -36,20 +48,32 @@ func NewSessionMemoryScraper() Scraper {
 	return &sessionMemoryScraper{}
 }
 
-type sessionMemoryScraper struct{}
+type sessionMemoryScraper struct {
+	baseScraper
+}
 
 func (sessionMemoryScraper) Name() string {
 	return "sessionMemoryScraper"
 }
 
-func (sessionMemoryScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
-	sessionMemorySql :=sessionMemorySql_v6;
-	if ver > 3 && ver < 6{
-		sessionMemorySql=sessionMemorySql_v5;
+func (s *sessionMemoryScraper) Configure(opts ScraperOptions) error {
+	if err := opts.check(supportedOptions{detailMetrics: true}); err != nil {
+		return err
+	}
+
+	s.opts = opts
+
+	return nil
+}
+
+func (s *sessionMemoryScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+	sessionMemorySql := sessionMemorySql_v6
+	if ver > 3 && ver < 6 {
+		sessionMemorySql = sessionMemorySql_v5
 	}
 
-	rows, err := db.Query(sessionMemorySql)
-	logger.Infof("Query Database: %s",sessionMemorySql)
+	rows, err := db.QueryContext(ctx, sessionMemorySql)
+	logger.Infof("Query Database: %s", sessionMemorySql)
 
 	if err != nil {
 		return err
@@ -57,15 +81,18 @@ func (sessionMemoryScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver
 
 	defer rows.Close()
 
+	details := make([]DetailRow, 0)
+	guard := s.env.guard(s.Name(), s.opts)
+	totals := make(map[string]float64)
+
 	for rows.Next() {
-		var pid,sess_id,vmem_max_seg,vmem_avg,vmem_total,query string
+		var pid, sess_id, vmem_max_seg, vmem_avg, vmem_total, query string
 		var datname, usename sql.NullString
 		var currentTime time.Time
-//		var count int64
 		err = rows.Scan(&currentTime,
-			&datname,
 			&pid,
 			&sess_id,
+			&datname,
 			&usename,
 			&vmem_max_seg,
 			&vmem_avg,
@@ -76,17 +103,51 @@ func (sessionMemoryScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver
 			return err
 		}
 
-		ch <- prometheus.MustNewConstMetric(sessionMemoryDesc, prometheus.GaugeValue, 
+		fingerprint, queryHash := fingerprintQuery(query)
+
+		if total, err := strconv.ParseFloat(vmem_total, 64); err == nil {
+			totals[datname.String] += total
+		}
+
+		details = append(details, DetailRow{
+			"pid":          pid,
+			"sess_id":      sess_id,
+			"datname":      datname.String,
+			"usename":      usename.String,
+			"vmem_max_seg": vmem_max_seg,
+			"vmem_avg":     vmem_avg,
+			"vmem_total":   vmem_total,
+			"query":        query,
+			"fingerprint":  fingerprint,
+			"query_hash":   queryHash,
+		})
+
+		if !s.opts.detailMetrics() {
+			continue
+		}
+
+		guard.emit(ch, sessionMemoryDesc,
 			float64(currentTime.UTC().Unix()),
-			datname.String, 
 			pid,
 			sess_id,
+			datname.String,
 			usename.String,
 			vmem_max_seg,
 			vmem_avg,
 			vmem_total,
-			query)
+			fingerprint,
+			queryHash)
 	}
 
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for datname, total := range totals {
+		ch <- prometheus.MustNewConstMetric(sessionMemoryTotalDesc, prometheus.GaugeValue, total, datname)
+	}
+
+	s.env.publish(s.Name(), DatasetSessionMemory, details)
+
 	return nil
 }
@@ -0,0 +1,158 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	logger "github.com/prometheus/common/log"
+)
+
+/**
+ * pg_stat_database抓取器：按数据库输出事务、块读取、元组、冲突、临时文件和死锁的累计值（_total计数器），
+ * 命中率、提交率等比例在PromQL中计算。pg_stat_database是视图，不能用gp_dist_random，
+ * 因此直接在gp_dist_random('pg_database')上调用视图所用的pg_stat_get_db_*函数，在每个segment上执行后汇总
+ */
+
+// pg_stat_database的一列，minVer为支持该列的最低大版本.
+type statDatabaseColumn struct {
+	name   string
+	expr   string
+	minVer int
+	desc   *prometheus.Desc
+}
+
+var statDatabaseColumns = []*statDatabaseColumn{
+	newStatDatabaseColumn("xact_commit", "pg_stat_get_db_xact_commit(d.oid)", 0, "Number of transactions committed in the database"),
+	newStatDatabaseColumn("xact_rollback", "pg_stat_get_db_xact_rollback(d.oid)", 0, "Number of transactions rolled back in the database"),
+	newStatDatabaseColumn("blks_read", "pg_stat_get_db_blocks_fetched(d.oid) - pg_stat_get_db_blocks_hit(d.oid)", 0, "Number of disk blocks read in the database"),
+	newStatDatabaseColumn("blks_hit", "pg_stat_get_db_blocks_hit(d.oid)", 0, "Number of disk blocks found in the buffer cache in the database"),
+	newStatDatabaseColumn("tup_returned", "pg_stat_get_db_tuples_returned(d.oid)", 0, "Number of rows returned by queries in the database"),
+	newStatDatabaseColumn("tup_fetched", "pg_stat_get_db_tuples_fetched(d.oid)", 0, "Number of rows fetched by queries in the database"),
+	newStatDatabaseColumn("tup_inserted", "pg_stat_get_db_tuples_inserted(d.oid)", 0, "Number of rows inserted by queries in the database"),
+	newStatDatabaseColumn("tup_updated", "pg_stat_get_db_tuples_updated(d.oid)", 0, "Number of rows updated by queries in the database"),
+	newStatDatabaseColumn("tup_deleted", "pg_stat_get_db_tuples_deleted(d.oid)", 0, "Number of rows deleted by queries in the database"),
+	newStatDatabaseColumn("conflicts", "pg_stat_get_db_conflict_all(d.oid)", 6, "Number of queries canceled due to conflicts with recovery in the database"),
+	newStatDatabaseColumn("temp_files", "pg_stat_get_db_temp_files(d.oid)", 6, "Number of temporary files created by queries in the database"),
+	newStatDatabaseColumn("temp_bytes", "pg_stat_get_db_temp_bytes(d.oid)", 6, "Total amount of data written to temporary files by queries in the database"),
+	newStatDatabaseColumn("deadlocks", "pg_stat_get_db_deadlocks(d.oid)", 6, "Number of deadlocks detected in the database"),
+}
+
+func newStatDatabaseColumn(name, expr string, minVer int, help string) *statDatabaseColumn {
+	return &statDatabaseColumn{
+		name:   name,
+		expr:   expr,
+		minVer: minVer,
+		desc: newDesc(
+			prometheus.BuildFQName(namespace, subSystemServer, "stat_database_"+name+"_total"),
+			help,
+			[]string{"datname"}, nil,
+		),
+	}
+}
+
+func NewStatDatabaseScraper() Scraper {
+	return &statDatabaseScraper{}
+}
+
+type statDatabaseScraper struct {
+	baseScraper
+}
+
+func (statDatabaseScraper) Name() string {
+	return "stat_database_scraper"
+}
+
+func (s *statDatabaseScraper) Configure(opts ScraperOptions) error {
+	if err := opts.check(supportedOptions{databases: true, aggregateSegments: true}); err != nil {
+		return err
+	}
+
+	s.opts = opts
+
+	return nil
+}
+
+func (s *statDatabaseScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+	databases, err := s.env.databases(ctx, db, s.opts.Databases)
+	if err != nil {
+		return err
+	}
+
+	allowed := make(map[string]bool, len(databases))
+	for _, dbname := range databases {
+		allowed[dbname] = true
+	}
+
+	columns := make([]*statDatabaseColumn, 0, len(statDatabaseColumns))
+	for _, column := range statDatabaseColumns {
+		if ver >= column.minVer {
+			columns = append(columns, column)
+		}
+	}
+
+	statSql := statDatabaseSql(columns, s.opts.AggregateSegments)
+
+	rows, err := db.QueryContext(ctx, statSql)
+	logger.Infof("Query Database: %s", statSql)
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	values := make([]float64, len(columns))
+	dest := make([]interface{}, len(columns)+1)
+	for i := range values {
+		dest[i+1] = &values[i]
+	}
+
+	for rows.Next() {
+		var datname string
+		dest[0] = &datname
+
+		if err = rows.Scan(dest...); err != nil {
+			return err
+		}
+
+		if !allowed[datname] {
+			continue
+		}
+
+		for i, column := range columns {
+			ch <- prometheus.MustNewConstMetric(column.desc, prometheus.CounterValue, values[i], datname)
+		}
+	}
+
+	return rows.Err()
+}
+
+/**
+* 函数：statDatabaseSql
+* 功能：生成查询指定列的SQL，aggregateSegments为true时汇总master和所有segment上的值，否则只查询master
+ */
+func statDatabaseSql(columns []*statDatabaseColumn, aggregateSegments bool) string {
+	exprs := make([]string, 0, len(columns))
+	sums := make([]string, 0, len(columns))
+
+	for _, column := range columns {
+		exprs = append(exprs, fmt.Sprintf("%s as %s", column.expr, column.name))
+		sums = append(sums, fmt.Sprintf("coalesce(sum(%s), 0)::float8", column.name))
+	}
+
+	sources := []string{"pg_database d"}
+	if aggregateSegments {
+		sources = append(sources, "gp_dist_random('pg_database') d")
+	}
+
+	selects := make([]string, 0, len(sources))
+	for _, source := range sources {
+		selects = append(selects, fmt.Sprintf("select d.datname, %s from %s", strings.Join(exprs, ", "), source))
+	}
+
+	return fmt.Sprintf("select datname, %s from (%s) t group by datname;",
+		strings.Join(sums, ", "), strings.Join(selects, " union all "))
+}
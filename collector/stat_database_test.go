@@ -0,0 +1,111 @@
+package collector
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+)
+
+func TestStatDatabaseSql(t *testing.T) {
+	cases := []struct {
+		name              string
+		aggregateSegments bool
+		contains          []string
+		excludes          []string
+	}{
+		{
+			name:     "master only",
+			contains: []string{"from pg_database d", "coalesce(sum(xact_commit), 0)::float8", "pg_stat_get_db_xact_commit(d.oid) as xact_commit"},
+			excludes: []string{"gp_dist_random", "union all"},
+		},
+		{
+			name:              "aggregate segments",
+			aggregateSegments: true,
+			contains:          []string{"from pg_database d union all select", "from gp_dist_random('pg_database') d", "group by datname"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			statSql := statDatabaseSql(statDatabaseColumns, c.aggregateSegments)
+
+			for _, s := range c.contains {
+				if !strings.Contains(statSql, s) {
+					t.Errorf("sql does not contain %q: %s", s, statSql)
+				}
+			}
+
+			for _, s := range c.excludes {
+				if strings.Contains(statSql, s) {
+					t.Errorf("sql contains %q: %s", s, statSql)
+				}
+			}
+		})
+	}
+}
+
+func TestStatDatabaseScraper(t *testing.T) {
+	cases := []struct {
+		name              string
+		ver               int
+		aggregateSegments bool
+		columns           int
+		contains          []string
+		excludes          []string
+	}{
+		{name: "v5", ver: 5, columns: 9, excludes: []string{"conflicts", "temp_files", "deadlocks", "gp_dist_random"}},
+		{name: "v6", ver: 6, columns: 13, contains: []string{"conflicts", "temp_bytes", "deadlocks"}, excludes: []string{"gp_dist_random"}},
+		{name: "v7 aggregate segments", ver: 7, aggregateSegments: true, columns: 13, contains: []string{"deadlocks", "gp_dist_random('pg_database')"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			row := func(datname string) []driver.Value {
+				values := []driver.Value{datname}
+				for i := 0; i < c.columns; i++ {
+					values = append(values, float64(i+1))
+				}
+
+				return values
+			}
+
+			f, db := newFakeDB()
+			f.on("datallowconn", []string{"datname", "datallowconn"},
+				[]driver.Value{"postgres", true}, []driver.Value{"gpperfmon", true})
+			f.on("pg_stat_get_db_xact_commit", make([]string, c.columns+1), row("postgres"), row("gpperfmon"))
+
+			s := NewStatDatabaseScraper().(*statDatabaseScraper)
+			if err := s.Configure(ScraperOptions{Databases: DatabaseFilter{Exclude: []string{"gpperfmon"}}, AggregateSegments: c.aggregateSegments}); err != nil {
+				t.Fatal(err)
+			}
+
+			s.setEnv(&scraperEnv{conns: newConnManager(CollectorOptions{})})
+
+			metrics, err := scrapeMetrics(t, s, db, c.ver)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, query := range c.contains {
+				if f.executed(query) == 0 {
+					t.Errorf("query containing %q was not executed", query)
+				}
+			}
+
+			for _, query := range c.excludes {
+				if f.executed(query) != 0 {
+					t.Errorf("query containing %q was executed", query)
+				}
+			}
+
+			// 每列一个计数器，被过滤的数据库不输出
+			if len(metrics) != c.columns {
+				t.Errorf("got %d metrics, expected %d: %v", len(metrics), c.columns, metrics)
+			}
+
+			if v := metrics[`hashdata_server_stat_database_xact_commit_total{datname="postgres"}`]; v != 1 {
+				t.Errorf("xact_commit = %v, expected 1", v)
+			}
+		})
+	}
+}
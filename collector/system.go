@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"database/sql"
 	"github.com/prometheus/client_golang/prometheus"
 	logger "github.com/prometheus/common/log"
@@ -16,133 +17,133 @@ const (
 )
 
 var (
-	memTotalDesc = prometheus.NewDesc(
+	memTotalDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "mem_total_bytes"),
 		"Segment or master hostname associated with these system metrics",
 		[]string{"hostname"}, nil,
 	)
 
-	memUsedDesc = prometheus.NewDesc(
+	memUsedDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "mem_used_bytes"),
 		"Total system memory in Bytes for this host",
 		[]string{"hostname"}, nil,
 	)
 
-	memActualUsedDesc = prometheus.NewDesc(
+	memActualUsedDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "mem_actual_used_bytes"),
 		"Used actual memory in Bytes for this host",
 		[]string{"hostname"}, nil,
 	)
 
-	memActualFreeDesc = prometheus.NewDesc(
+	memActualFreeDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "mem_actual_free_bytes"),
 		"Free actual memory in Bytes for this host",
 		[]string{"hostname"}, nil,
 	)
 
-	swapTotalDesc = prometheus.NewDesc(
+	swapTotalDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "swap_total_bytes"),
 		"Total swap space in Bytes for this host",
 		[]string{"hostname"}, nil,
 	)
 
-	swapUsedDesc = prometheus.NewDesc(
+	swapUsedDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "swap_used_bytes"),
 		"Used swap space in Bytes for this host",
 		[]string{"hostname"}, nil,
 	)
 
-	swapPageInDesc = prometheus.NewDesc(
+	swapPageInDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "swap_page_in"),
 		"Number of swap pages in",
 		[]string{"hostname"}, nil,
 	)
 
-	swapPageOutDesc = prometheus.NewDesc(
+	swapPageOutDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "swap_page_out"),
 		"Number of swap pages out",
 		[]string{"hostname"}, nil,
 	)
 
-	cpuUserDesc = prometheus.NewDesc(
+	cpuUserDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "cpu_user_percent"),
 		"CPU usage by the hashdata system user",
 		[]string{"hostname"}, nil,
 	)
 
-	cpuSysDesc = prometheus.NewDesc(
+	cpuSysDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "cpu_sys_percent"),
 		"CPU usage for this host",
 		[]string{"hostname"}, nil,
 	)
 
-	cpuIdleDesc = prometheus.NewDesc(
+	cpuIdleDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "cpu_idle_percent"),
 		"Idle CPU capacity at metric collection time",
 		[]string{"hostname"}, nil,
 	)
 
-	cpuAvg1mDesc = prometheus.NewDesc(
+	cpuAvg1mDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "cpu_avg_usage_1m_percent"),
 		"CPU load average for the prior one-minute period",
 		[]string{"hostname"}, nil,
 	)
 
-	cpuAvg5mDesc = prometheus.NewDesc(
+	cpuAvg5mDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "cpu_avg_usage_5m_percent"),
 		"CPU load average for the prior five-minutes period",
 		[]string{"hostname"}, nil,
 	)
 
-	cpuAvg15mDesc = prometheus.NewDesc(
+	cpuAvg15mDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "cpu_avg_usage_15m_percent"),
 		"CPU load average for the prior fifteen-minutes period",
 		[]string{"hostname"}, nil,
 	)
 
-	diskRoDesc = prometheus.NewDesc(
+	diskRoDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "disk_ro_rate"),
 		"Disk read operations per second",
 		[]string{"hostname"}, nil,
 	)
 
-	diskWoDesc = prometheus.NewDesc(
+	diskWoDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "disk_wo_rate"),
 		"Disk write operations per second",
 		[]string{"hostname"}, nil,
 	)
 
-	diskRbDesc = prometheus.NewDesc(
+	diskRbDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "disk_rb_rate"),
 		"Bytes per second for disk read operations",
 		[]string{"hostname"}, nil,
 	)
 
-	diskWbDesc = prometheus.NewDesc(
+	diskWbDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "disk_wb_rate"),
 		"Bytes per second for disk write operations",
 		[]string{"hostname"}, nil,
 	)
 
-	netRpDesc = prometheus.NewDesc(
+	netRpDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "net_rp_rate"),
 		"Packets per second on the system network for read operations",
 		[]string{"hostname"}, nil,
 	)
 
-	netWpDesc = prometheus.NewDesc(
+	netWpDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "net_wp_rate"),
 		"Packets per second on the system network for write operations",
 		[]string{"hostname"}, nil,
 	)
 
-	netRbDesc = prometheus.NewDesc(
+	netRbDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "net_rb_rate"),
 		"Bytes per second on the system network for read operations",
 		[]string{"hostname"}, nil,
 	)
 
-	netWbDesc = prometheus.NewDesc(
+	netWbDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemNode, "net_wb_rate"),
 		"Bytes per second on the system network for write operations",
 		[]string{"hostname"}, nil,
@@ -150,17 +151,19 @@ var (
 )
 
 func NewSystemScraper() Scraper {
-	return systemScraper{}
+	return &systemScraper{}
 }
 
-type systemScraper struct{}
+type systemScraper struct {
+	baseScraper
+}
 
 func (systemScraper) Name() string {
 	return "systemScraper"
 }
 
-func (systemScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
-	rows, err := db.Query(systemMetricsSql)
+func (systemScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+	rows, err := db.QueryContext(ctx, systemMetricsSql)
 	logger.Infof("Query Database: %s",systemMetricsSql)
 
 	if err != nil {
@@ -1,10 +1,10 @@
 package collector
 
 import (
-	"container/list"
+	"context"
 	"database/sql"
-	"os"
-	"strings"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -12,7 +12,6 @@ import (
 )
 
 const (
-	getDBNameSql      = `select datname from pg_database where datname not in ('template0','template1');`
 	bloatHeapTableSql = `
 		SELECT now(),current_database(),bdinspname,bdirelname,bdirelpages,bdiexppages,(
 		case 
@@ -25,88 +24,152 @@ const (
 )
 
 var (
-	bloatDesc = prometheus.NewDesc(
+	bloatDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemServer, "heap_table_bloat_detail"),
 		"Tables bloat detail for HashData database",
 		[]string{"datname", "bdinspname", "bdirelname", "bdirelpages", "bdiexppages", "bloat_state"},
 		nil,
 	)
+
+	bloatTablesDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "heap_table_bloat_tables"),
+		"Number of heap tables by database and bloat state, 1 for moderate and 2 for significant",
+		[]string{"datname", "bloat_state"},
+		nil,
+	)
 )
 
 func NewbloatScraper() Scraper {
 	return &bloatScraper{}
 }
 
-type bloatScraper struct{}
+type bloatScraper struct {
+	baseScraper
+}
 
 func (bloatScraper) Name() string {
 	return "bloatScraper"
 }
 
-func (bloatScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
-	rows, err := db.Query(getDBNameSql)
-	logger.Infof("Query Database: %s", getDBNameSql)
+func (s *bloatScraper) Configure(opts ScraperOptions) error {
+	if err := opts.check(supportedOptions{databases: true, detailMetrics: true}); err != nil {
+		return err
+	}
 
+	s.opts = opts
+
+	return nil
+}
+
+func (s *bloatScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+	names, err := s.env.databases(ctx, db, s.opts.Databases)
 	if err != nil {
 		return err
 	}
 
-	defer rows.Close()
-
-	names := list.New()
-	for rows.Next() {
-		var dbname string
-		err = rows.Scan(&dbname)
+	details := make([]DetailRow, 0)
+	tables := make(map[[2]string]float64)
+	errs := make([]error, 0)
 
+	for _, dbname := range names {
+		rows, err := s.queryDatabase(ctx, dbname)
 		if err != nil {
-			return err
+			errs = append(errs, err)
+			continue
 		}
-		names.PushBack(dbname)
+
+		for _, row := range rows {
+			tables[[2]string{row["datname"], row["bloat_state"]}]++
+		}
+
+		details = append(details, rows...)
 	}
 
-	for item := names.Front(); nil != item; item = item.Next() {
-		dbname := item.Value.(string)
-		dataSourceName := os.Getenv("GPDB_DATA_SOURCE_URL")
-		newDataSourceName := strings.Replace(dataSourceName, "/postgres", "/"+dbname, 1)
-		logger.Infof("Connection string is : %s", newDataSourceName)
+	for key, count := range tables {
+		ch <- prometheus.MustNewConstMetric(bloatTablesDesc, prometheus.GaugeValue, count, key[0], key[1])
+	}
 
-		conn, err := sql.Open("postgres", newDataSourceName)
-		defer conn.Close()
+	if s.opts.detailMetrics() {
+		// 按膨胀程度、浪费的页数从大到小输出，超出max_rows的表被丢弃
+		sort.SliceStable(details, func(i, j int) bool {
+			if details[i]["bloat_state"] != details[j]["bloat_state"] {
+				return details[i]["bloat_state"] > details[j]["bloat_state"]
+			}
 
-		rows, err := conn.Query(bloatHeapTableSql)
-		logger.Infof("Query Database: %s", bloatHeapTableSql)
+			return wastedPages(details[i]) > wastedPages(details[j])
+		})
 
-		if err != nil {
-			return err
+		guard := s.env.guard(s.Name(), s.opts)
+		now := float64(time.Now().UTC().Unix())
+
+		for _, row := range details {
+			guard.emit(ch, bloatDesc, now,
+				row["datname"],
+				row["bdinspname"],
+				row["bdirelname"],
+				row["bdirelpages"],
+				row["bdiexppages"],
+				row["bloat_state"])
 		}
+	}
 
-		defer rows.Close()
-
-		for rows.Next() {
-			var datname, bdinspname, bdirelname, bdirelpages, bdiexppages, bloat_state string
-			var currentTime time.Time
-			err = rows.Scan(&currentTime,
-				&datname,
-				&bdinspname,
-				&bdirelname,
-				&bdirelpages,
-				&bdiexppages,
-				&bloat_state)
-
-			if err != nil {
-				return err
-			}
+	s.env.publish(s.Name(), DatasetBloat, details)
+
+	return combineErr(errs...)
+}
+
+/**
+* 函数：queryDatabase
+* 功能：查询一个数据库中的膨胀表
+ */
+func (s *bloatScraper) queryDatabase(ctx context.Context, dbname string) ([]DetailRow, error) {
+	conn, err := s.env.conns.database(dbname)
+	if err != nil {
+		return nil, err
+	}
 
-			ch <- prometheus.MustNewConstMetric(bloatDesc, prometheus.GaugeValue,
-				float64(currentTime.UTC().Unix()),
-				datname,
-				bdinspname,
-				bdirelname,
-				bdirelpages,
-				bdiexppages,
-				bloat_state)
+	rows, err := conn.QueryContext(ctx, bloatHeapTableSql)
+	logger.Infof("Query Database: %s on %s", bloatHeapTableSql, dbname)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	details := make([]DetailRow, 0)
+
+	for rows.Next() {
+		var datname, bdinspname, bdirelname, bdirelpages, bdiexppages, bloat_state string
+		var currentTime time.Time
+		err = rows.Scan(&currentTime,
+			&datname,
+			&bdinspname,
+			&bdirelname,
+			&bdirelpages,
+			&bdiexppages,
+			&bloat_state)
+
+		if err != nil {
+			return nil, err
 		}
 
+		details = append(details, DetailRow{
+			"datname":     datname,
+			"bdinspname":  bdinspname,
+			"bdirelname":  bdirelname,
+			"bdirelpages": bdirelpages,
+			"bdiexppages": bdiexppages,
+			"bloat_state": bloat_state,
+		})
 	}
-	return nil
+
+	return details, rows.Err()
+}
+
+func wastedPages(row DetailRow) float64 {
+	relpages, _ := strconv.ParseFloat(row["bdirelpages"], 64)
+	exppages, _ := strconv.ParseFloat(row["bdiexppages"], 64)
+
+	return relpages - exppages
 }
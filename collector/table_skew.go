@@ -1,10 +1,10 @@
 package collector
 
 import (
-	"container/list"
 	"context"
 	"database/sql"
-	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -86,10 +86,10 @@ const (
 		GROUP BY n.nspname, c.relname, db.segment_id
 		) sub
 		group by 1,2
-		--Extract only table bigger than 1 GB
-		--   and with a skew greater than 20%
-		having sum(sub.size)/(1024^3) > 1
-		and (100*(max(sub.size) - min(sub.size))/greatest(max(sub.size),1))::numeric(6,2) > 20
+		--Extract only table bigger than min_size_gb (default 1 GB)
+		--   and with a skew greater than min_skew_percent (default 20%)
+		having sum(sub.size)/(1024^3) > {{min_size_gb}}
+		and (100*(max(sub.size) - min(sub.size))/greatest(max(sub.size),1))::numeric(6,2) > {{min_skew_percent}}
 		order by vtotal_size_GB desc, vseg_gap_min_max_percent desc
 		limit 100 ) loop
 		schema_name         = v_res.vschema_name;
@@ -121,112 +121,208 @@ const (
 )
 
 var (
-	ctx          context.Context
-	dataSkewDesc = prometheus.NewDesc(
+	dataSkewDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemServer, "dataSkew_detail"),
 		"Tables > 20% data skew across segments.",
 		[]string{"schema_name", "table_name", "total_size_gb", "seg_min_size_gb", "seg_max_size_gb", "seg_avg_size_gb", "seg_gap_min_max_percent", "seg_gap_min_max_gb", "nb_empty_seg"},
 		nil,
 	)
+
+	dataSkewTablesDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "data_skew_tables"),
+		"Number of tables exceeding the data skew thresholds in each database",
+		[]string{"datname"},
+		nil,
+	)
+
+	dataSkewMaxGapDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "data_skew_max_gap_percent"),
+		"Largest gap in percent between the smallest and biggest segment of a skewed table in each database",
+		[]string{"datname"},
+		nil,
+	)
 )
 
 func NewDataSkewScraper() Scraper {
 	return &DataSkewScraper{}
 }
 
-type DataSkewScraper struct{}
+type DataSkewScraper struct {
+	baseScraper
+}
 
 func (DataSkewScraper) Name() string {
 	return "DataSkewScraper"
 }
 
-func (DataSkewScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
-	rows, err := db.Query(getDBNameSql)
-	logger.Infof("Query Database: %s", getDBNameSql)
+// 每次抓取都会创建函数fn_get_skew以及外部表db_files_ext.
+func (DataSkewScraper) writesDatabase() {}
 
-	if err != nil {
+func (s *DataSkewScraper) Configure(opts ScraperOptions) error {
+	if err := opts.check(supportedOptions{databases: true, detailMetrics: true, thresholds: []string{"min_size_gb", "min_skew_percent"}}); err != nil {
 		return err
 	}
 
-	defer rows.Close()
+	s.opts = opts
 
-	names := list.New()
-	for rows.Next() {
-		var dbname string
-		err = rows.Scan(&dbname)
+	return nil
+}
+
+func (s *DataSkewScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+	names, err := s.env.databases(ctx, db, s.opts.Databases)
+	if err != nil {
+		return err
+	}
+
+	details := make([]DetailRow, 0)
+	tables := make(map[string]float64)
+	maxGap := make(map[string]float64)
+	errs := make([]error, 0)
 
+	for _, dbname := range names {
+		rows, err := s.queryDatabase(ctx, dbname)
 		if err != nil {
-			return err
+			errs = append(errs, err)
+			continue
 		}
-		names.PushBack(dbname)
-	}
 
-	for item := names.Front(); nil != item; item = item.Next() {
-		dbname := item.Value.(string)
-		dataSourceName := os.Getenv("GPDB_DATA_SOURCE_URL")
-		newDataSourceName := strings.Replace(dataSourceName, "/postgres", "/"+dbname, 1)
-		logger.Infof("Connection string is : %s", newDataSourceName)
+		for _, row := range rows {
+			tables[dbname]++
+			if gap, err := strconv.ParseFloat(row["seg_gap_min_max_percent"], 64); err == nil && gap > maxGap[dbname] {
+				maxGap[dbname] = gap
+			}
+		}
 
-		conn, err := sql.Open("postgres", newDataSourceName)
-		defer conn.Close()
+		details = append(details, rows...)
+	}
 
-		_, err = conn.Exec(create_data_skew_fn_sql)
-		logger.Infof("Query Database: %s", create_data_skew_fn_sql)
+	for dbname, count := range tables {
+		ch <- prometheus.MustNewConstMetric(dataSkewTablesDesc, prometheus.GaugeValue, count, dbname)
+		ch <- prometheus.MustNewConstMetric(dataSkewMaxGapDesc, prometheus.GaugeValue, maxGap[dbname], dbname)
+	}
 
-		if err != nil {
-			return err
+	if s.opts.detailMetrics() {
+		// 按表大小、倾斜程度从大到小输出，超出max_rows的表被丢弃
+		sort.SliceStable(details, func(i, j int) bool {
+			si, _ := strconv.ParseFloat(details[i]["total_size_gb"], 64)
+			sj, _ := strconv.ParseFloat(details[j]["total_size_gb"], 64)
+			if si != sj {
+				return si > sj
+			}
+
+			gi, _ := strconv.ParseFloat(details[i]["seg_gap_min_max_percent"], 64)
+			gj, _ := strconv.ParseFloat(details[j]["seg_gap_min_max_percent"], 64)
+
+			return gi > gj
+		})
+
+		guard := s.env.guard(s.Name(), s.opts)
+		now := float64(time.Now().UTC().Unix())
+
+		for _, row := range details {
+			guard.emit(ch, dataSkewDesc, now,
+				row["schema_name"],
+				row["table_name"],
+				row["total_size_gb"],
+				row["seg_min_size_gb"],
+				row["seg_max_size_gb"],
+				row["seg_avg_size_gb"],
+				row["seg_gap_min_max_percent"],
+				row["seg_gap_min_max_gb"],
+				row["nb_empty_seg"])
 		}
+	}
 
-		_, err = conn.Exec("SET client_min_messages TO WARNING;")
-		logger.Infof("Query Database: %s", "SET client_min_messages TO WARNING;")
+	s.env.publish(s.Name(), DatasetSkew, details)
 
-		if err != nil {
-			return err
-		}
+	return combineErr(errs...)
+}
 
-		rows, err := conn.Query(get_data_skew_sql)
-		logger.Infof("Query Database: %s", get_data_skew_sql)
+/**
+* 函数：queryDatabase
+* 功能：在一个数据库中创建fn_get_skew函数并查询倾斜的表，所有语句在同一个连接上执行
+ */
+func (s *DataSkewScraper) queryDatabase(ctx context.Context, dbname string) ([]DetailRow, error) {
+	pool, err := s.env.conns.database(dbname)
+	if err != nil {
+		return nil, err
+	}
 
-		if err != nil {
-			logger.Errorf("get metrics for scraper, error:%v", err.Error())
-			return err
-		}
+	conn, err := pool.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-		defer rows.Close()
-
-		//	if err = tx.Commit(); err != nil {
-		//		return err
-		//	}
-
-		for rows.Next() {
-			var schema_name, table_name, total_size_gb, seg_min_size_gb, seg_max_size_gb, seg_avg_size_gb,
-				seg_gap_min_max_percent, seg_gap_min_max_gb, nb_empty_seg string
-			err = rows.Scan(&schema_name,
-				&table_name,
-				&total_size_gb,
-				&seg_min_size_gb,
-				&seg_max_size_gb,
-				&seg_avg_size_gb,
-				&seg_gap_min_max_percent,
-				&seg_gap_min_max_gb,
-				&nb_empty_seg)
-
-			if err != nil {
-				return err
-			}
+	defer conn.Close()
+
+	createSql := s.createFunctionSql()
+	_, err = conn.ExecContext(ctx, createSql)
+	logger.Infof("Query Database: %s on %s", createSql, dbname)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = conn.ExecContext(ctx, "SET client_min_messages TO WARNING;")
+	logger.Infof("Query Database: %s", "SET client_min_messages TO WARNING;")
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.QueryContext(ctx, get_data_skew_sql)
+	logger.Infof("Query Database: %s on %s", get_data_skew_sql, dbname)
 
-			ch <- prometheus.MustNewConstMetric(dataSkewDesc, prometheus.GaugeValue,
-				float64(time.Now().UTC().Unix()),
-				schema_name,
-				table_name,
-				total_size_gb,
-				seg_min_size_gb,
-				seg_max_size_gb,
-				seg_avg_size_gb,
-				seg_gap_min_max_percent,
-				seg_gap_min_max_gb,
-				nb_empty_seg)
+	if err != nil {
+		logger.Errorf("get metrics for scraper, error:%v", err.Error())
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	details := make([]DetailRow, 0)
+
+	for rows.Next() {
+		var schema_name, table_name, total_size_gb, seg_min_size_gb, seg_max_size_gb, seg_avg_size_gb,
+			seg_gap_min_max_percent, seg_gap_min_max_gb, nb_empty_seg string
+		err = rows.Scan(&schema_name,
+			&table_name,
+			&total_size_gb,
+			&seg_min_size_gb,
+			&seg_max_size_gb,
+			&seg_avg_size_gb,
+			&seg_gap_min_max_percent,
+			&seg_gap_min_max_gb,
+			&nb_empty_seg)
+
+		if err != nil {
+			return nil, err
 		}
+
+		details = append(details, DetailRow{
+			"datname":                 dbname,
+			"schema_name":             schema_name,
+			"table_name":              table_name,
+			"total_size_gb":           total_size_gb,
+			"seg_min_size_gb":         seg_min_size_gb,
+			"seg_max_size_gb":         seg_max_size_gb,
+			"seg_avg_size_gb":         seg_avg_size_gb,
+			"seg_gap_min_max_percent": seg_gap_min_max_percent,
+			"seg_gap_min_max_gb":      seg_gap_min_max_gb,
+			"nb_empty_seg":            nb_empty_seg,
+		})
 	}
-	return nil
+
+	return details, rows.Err()
+}
+
+/**
+* 函数：createFunctionSql
+* 功能：使用配置的阈值生成创建fn_get_skew函数的语句
+ */
+func (s *DataSkewScraper) createFunctionSql() string {
+	return strings.NewReplacer(
+		"{{min_size_gb}}", strconv.FormatFloat(s.opts.threshold("min_size_gb", 1), 'f', -1, 64),
+		"{{min_skew_percent}}", strconv.FormatFloat(s.opts.threshold("min_skew_percent", 20), 'f', -1, 64),
+	).Replace(create_data_skew_fn_sql)
 }
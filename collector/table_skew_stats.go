@@ -0,0 +1,219 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	logger "github.com/prometheus/common/log"
+)
+
+/**
+ * 只读的数据倾斜抓取器：通过gp_dist_random('pg_class')在每个segment上执行pg_relation_size，
+ * 按表汇总各segment的大小分布，不创建函数、外部表，也不扫描表中的数据
+ */
+
+const (
+	// relstorage在7及以上版本中不存在，外部表的relkind为'f'.
+	tableSkewStatsSql_v6 = `
+		select n.nspname, c.relname, s.total_bytes, s.min_bytes, s.max_bytes, s.avg_bytes, s.empty_segments, s.skew_coefficient
+		from (
+			select oid,
+				sum(size) as total_bytes,
+				min(size) as min_bytes,
+				max(size) as max_bytes,
+				avg(size) as avg_bytes,
+				sum(case when size = 0 then 1 else 0 end) as empty_segments,
+				case when avg(size) > 0 then stddev_pop(size) / avg(size) * 100 else 0 end as skew_coefficient
+			from (
+				select oid, pg_relation_size(oid) as size
+				from gp_dist_random('pg_class')
+				where relkind = 'r' and relstorage not in ('x', 'v', 'f')
+			) seg
+			group by oid
+		) s
+		join pg_class c on c.oid = s.oid
+		join pg_namespace n on n.oid = c.relnamespace
+		where n.nspname not in ('pg_catalog', 'information_schema', 'gp_toolkit', 'pg_toast', 'pg_aoseg', 'pg_bitmapindex')
+		and n.nspname not like 'pg_temp%'
+		and s.total_bytes >= $1::float8 * 1073741824
+		and s.skew_coefficient >= $2::float8
+		order by s.total_bytes desc`
+	tableSkewStatsSql_v7 = `
+		select n.nspname, c.relname, s.total_bytes, s.min_bytes, s.max_bytes, s.avg_bytes, s.empty_segments, s.skew_coefficient
+		from (
+			select oid,
+				sum(size) as total_bytes,
+				min(size) as min_bytes,
+				max(size) as max_bytes,
+				avg(size) as avg_bytes,
+				sum(case when size = 0 then 1 else 0 end) as empty_segments,
+				case when avg(size) > 0 then stddev_pop(size) / avg(size) * 100 else 0 end as skew_coefficient
+			from (
+				select oid, pg_relation_size(oid) as size
+				from gp_dist_random('pg_class')
+				where relkind = 'r'
+			) seg
+			group by oid
+		) s
+		join pg_class c on c.oid = s.oid
+		join pg_namespace n on n.oid = c.relnamespace
+		where n.nspname not in ('pg_catalog', 'information_schema', 'gp_toolkit', 'pg_toast', 'pg_aoseg', 'pg_bitmapindex')
+		and n.nspname not like 'pg_temp%'
+		and s.total_bytes >= $1::float8 * 1073741824
+		and s.skew_coefficient >= $2::float8
+		order by s.total_bytes desc`
+)
+
+var (
+	tableSkewLabels = []string{"datname", "schema_name", "table_name"}
+
+	tableSkewCoefficientDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "table_skew_coefficient"),
+		"Coefficient of variation in percent of the table size across segments (stddev / avg * 100)",
+		tableSkewLabels, nil,
+	)
+
+	tableSizeBytesDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "table_size_bytes"),
+		"Total size in bytes of the table on all segments",
+		tableSkewLabels, nil,
+	)
+
+	tableSegmentMinBytesDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "table_segment_min_bytes"),
+		"Size in bytes of the table on its smallest segment",
+		tableSkewLabels, nil,
+	)
+
+	tableSegmentMaxBytesDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "table_segment_max_bytes"),
+		"Size in bytes of the table on its biggest segment",
+		tableSkewLabels, nil,
+	)
+
+	tableSegmentAvgBytesDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "table_segment_avg_bytes"),
+		"Average size in bytes of the table per segment",
+		tableSkewLabels, nil,
+	)
+
+	tableEmptySegmentsDesc = newDesc(
+		prometheus.BuildFQName(namespace, subSystemServer, "table_empty_segments"),
+		"Number of segments on which the table has no data",
+		tableSkewLabels, nil,
+	)
+)
+
+func NewTableSkewScraper() Scraper {
+	return &tableSkewScraper{}
+}
+
+type tableSkewScraper struct {
+	baseScraper
+}
+
+// 一个表在各segment上的大小分布.
+type tableSkewStats struct {
+	datname, schemaName, tableName                                       string
+	totalBytes, minBytes, maxBytes, avgBytes, emptySegments, coefficient float64
+}
+
+func (tableSkewScraper) Name() string {
+	return "table_skew_scraper"
+}
+
+func (s *tableSkewScraper) Configure(opts ScraperOptions) error {
+	if err := opts.check(supportedOptions{databases: true, limits: true, thresholds: []string{"min_size_gb", "min_skew_coefficient"}}); err != nil {
+		return err
+	}
+
+	s.opts = opts
+
+	return nil
+}
+
+func (s *tableSkewScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+	names, err := s.env.databases(ctx, db, s.opts.Databases)
+	if err != nil {
+		return err
+	}
+
+	querySql := tableSkewStatsSql_v6
+	if ver >= 7 {
+		querySql = tableSkewStatsSql_v7
+	}
+
+	errs := make([]error, 0)
+	tables := make([]tableSkewStats, 0)
+
+	for _, dbname := range names {
+		stats, err := s.queryDatabase(ctx, dbname, querySql)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		tables = append(tables, stats...)
+	}
+
+	// 按表大小从大到小输出，超出max_rows的表被丢弃
+	sort.SliceStable(tables, func(i, j int) bool {
+		return tables[i].totalBytes > tables[j].totalBytes
+	})
+
+	guard := s.env.guard(s.Name(), s.opts)
+
+	for _, t := range tables {
+		if !guard.next() {
+			continue
+		}
+
+		labels := guard.labels(t.datname, t.schemaName, t.tableName)
+
+		ch <- prometheus.MustNewConstMetric(tableSkewCoefficientDesc, prometheus.GaugeValue, t.coefficient, labels...)
+		ch <- prometheus.MustNewConstMetric(tableSizeBytesDesc, prometheus.GaugeValue, t.totalBytes, labels...)
+		ch <- prometheus.MustNewConstMetric(tableSegmentMinBytesDesc, prometheus.GaugeValue, t.minBytes, labels...)
+		ch <- prometheus.MustNewConstMetric(tableSegmentMaxBytesDesc, prometheus.GaugeValue, t.maxBytes, labels...)
+		ch <- prometheus.MustNewConstMetric(tableSegmentAvgBytesDesc, prometheus.GaugeValue, t.avgBytes, labels...)
+		ch <- prometheus.MustNewConstMetric(tableEmptySegmentsDesc, prometheus.GaugeValue, t.emptySegments, labels...)
+	}
+
+	return combineErr(errs...)
+}
+
+/**
+* 函数：queryDatabase
+* 功能：查询一个数据库中超过阈值的表在各segment上的大小分布
+ */
+func (s *tableSkewScraper) queryDatabase(ctx context.Context, dbname, querySql string) ([]tableSkewStats, error) {
+	conn, err := s.env.conns.database(dbname)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.QueryContext(ctx, querySql, s.opts.threshold("min_size_gb", 1), s.opts.threshold("min_skew_coefficient", 0))
+	logger.Infof("Query Database: %s on %s", querySql, dbname)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	tables := make([]tableSkewStats, 0)
+
+	for rows.Next() {
+		t := tableSkewStats{datname: dbname}
+
+		err = rows.Scan(&t.schemaName, &t.tableName, &t.totalBytes, &t.minBytes, &t.maxBytes, &t.avgBytes, &t.emptySegments, &t.coefficient)
+		if err != nil {
+			return nil, err
+		}
+
+		tables = append(tables, t)
+	}
+
+	return tables, rows.Err()
+}
@@ -0,0 +1,291 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	logger "github.com/prometheus/common/log"
+)
+
+/**
+ * 表统计抓取器：输出tables中配置的表的扫描次数、元组变化、live/dead元组、距上次vacuum和analyze的时长以及块读取，
+ * 数据来源与pg_stat_user_tables、pg_statio_user_tables相同，在gp_dist_random('pg_class')和gp_dist_random('pg_index')上
+ * 调用pg_stat_get_*函数，汇总master和所有segment上的值。只抓取匹配tables.include的表，避免时间序列过多
+ */
+
+const (
+	tableCandidatesSql = `
+	select c.oid, n.nspname, c.relname
+	from pg_class c join pg_namespace n on n.oid = c.relnamespace
+	where c.relkind = 'r'
+	and n.nspname not in ('pg_catalog', 'information_schema', 'gp_toolkit')
+	and n.nspname not like 'pg_toast%'
+	and n.nspname not like 'pg_temp%';`
+
+	// %[1]s为n_mod_since_analyze的表达式，%[2]s为表的来源.
+	tableStatsSelect = `
+		select c.oid as relid,
+			pg_stat_get_numscans(c.oid) as seq_scan,
+			pg_stat_get_tuples_returned(c.oid) as seq_tup_read,
+			pg_stat_get_tuples_fetched(c.oid) as tup_fetch,
+			pg_stat_get_tuples_inserted(c.oid) as n_tup_ins,
+			pg_stat_get_tuples_updated(c.oid) as n_tup_upd,
+			pg_stat_get_tuples_deleted(c.oid) as n_tup_del,
+			pg_stat_get_live_tuples(c.oid) as n_live_tup,
+			pg_stat_get_dead_tuples(c.oid) as n_dead_tup,
+			%[1]s as n_mod_since_analyze,
+			pg_stat_get_blocks_fetched(c.oid) - pg_stat_get_blocks_hit(c.oid) as heap_blks_read,
+			pg_stat_get_blocks_hit(c.oid) as heap_blks_hit,
+			greatest(pg_stat_get_last_vacuum_time(c.oid), pg_stat_get_last_autovacuum_time(c.oid)) as last_vacuum,
+			greatest(pg_stat_get_last_analyze_time(c.oid), pg_stat_get_last_autoanalyze_time(c.oid)) as last_analyze
+		from %[2]s c
+		where c.oid = any($1::oid[])`
+
+	// %s为索引的来源.
+	indexStatsSelect = `
+		select i.indrelid as relid,
+			pg_stat_get_numscans(i.indexrelid) as idx_scan,
+			pg_stat_get_tuples_fetched(i.indexrelid) as idx_tup_fetch,
+			pg_stat_get_blocks_fetched(i.indexrelid) - pg_stat_get_blocks_hit(i.indexrelid) as idx_blks_read,
+			pg_stat_get_blocks_hit(i.indexrelid) as idx_blks_hit
+		from %s i
+		where i.indrelid = any($1::oid[])`
+
+	// %[1]s为master和segment上的表统计，%[2]s为master和segment上的索引统计.
+	tableStatsSql = `
+	select ts.relid,
+		ts.seq_scan, ts.seq_tup_read,
+		coalesce(ix.idx_scan, 0), coalesce(ix.idx_tup_fetch, 0) + ts.tup_fetch,
+		ts.n_tup_ins, ts.n_tup_upd, ts.n_tup_del,
+		ts.n_live_tup, ts.n_dead_tup, ts.n_mod_since_analyze,
+		ts.vacuum_age, ts.analyze_age,
+		ts.heap_blks_read, ts.heap_blks_hit,
+		coalesce(ix.idx_blks_read, 0), coalesce(ix.idx_blks_hit, 0)
+	from (
+		select relid,
+			sum(seq_scan)::float8 as seq_scan, sum(seq_tup_read)::float8 as seq_tup_read, sum(tup_fetch)::float8 as tup_fetch,
+			sum(n_tup_ins)::float8 as n_tup_ins, sum(n_tup_upd)::float8 as n_tup_upd, sum(n_tup_del)::float8 as n_tup_del,
+			sum(n_live_tup)::float8 as n_live_tup, sum(n_dead_tup)::float8 as n_dead_tup,
+			sum(n_mod_since_analyze)::float8 as n_mod_since_analyze,
+			extract(epoch from now() - max(last_vacuum))::float8 as vacuum_age,
+			extract(epoch from now() - max(last_analyze))::float8 as analyze_age,
+			sum(heap_blks_read)::float8 as heap_blks_read, sum(heap_blks_hit)::float8 as heap_blks_hit
+		from (%[1]s) t
+		group by relid
+	) ts left join (
+		select relid,
+			sum(idx_scan)::float8 as idx_scan, sum(idx_tup_fetch)::float8 as idx_tup_fetch,
+			sum(idx_blks_read)::float8 as idx_blks_read, sum(idx_blks_hit)::float8 as idx_blks_hit
+		from (%[2]s) i
+		group by relid
+	) ix on ix.relid = ts.relid;`
+)
+
+var tableStatsLabels = []string{"datname", "schema_name", "table_name"}
+
+// 表统计指标，顺序与tableStatsSql中relid之后的列相同.
+var tableStatsMetrics = []struct {
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+}{
+	{newTableStatsDesc("table_seq_scan_total", "Number of sequential scans initiated on the table"), prometheus.CounterValue},
+	{newTableStatsDesc("table_seq_tup_read_total", "Number of live rows fetched by sequential scans on the table"), prometheus.CounterValue},
+	{newTableStatsDesc("table_idx_scan_total", "Number of index scans initiated on the table"), prometheus.CounterValue},
+	{newTableStatsDesc("table_idx_tup_fetch_total", "Number of live rows fetched by index scans on the table"), prometheus.CounterValue},
+	{newTableStatsDesc("table_tup_inserted_total", "Number of rows inserted into the table"), prometheus.CounterValue},
+	{newTableStatsDesc("table_tup_updated_total", "Number of rows updated in the table"), prometheus.CounterValue},
+	{newTableStatsDesc("table_tup_deleted_total", "Number of rows deleted from the table"), prometheus.CounterValue},
+	{newTableStatsDesc("table_live_tuples", "Estimated number of live rows in the table"), prometheus.GaugeValue},
+	{newTableStatsDesc("table_dead_tuples", "Estimated number of dead rows in the table"), prometheus.GaugeValue},
+	{newTableStatsDesc("table_mod_since_analyze", "Estimated number of rows modified since the table was last analyzed"), prometheus.GaugeValue},
+	{newTableStatsDesc("table_last_vacuum_age_seconds", "Seconds since the table was last vacuumed manually or by autovacuum"), prometheus.GaugeValue},
+	{newTableStatsDesc("table_last_analyze_age_seconds", "Seconds since the table was last analyzed manually or by autovacuum"), prometheus.GaugeValue},
+	{newTableStatsDesc("table_heap_blks_read_total", "Number of disk blocks read from the table"), prometheus.CounterValue},
+	{newTableStatsDesc("table_heap_blks_hit_total", "Number of buffer hits in the table"), prometheus.CounterValue},
+	{newTableStatsDesc("table_idx_blks_read_total", "Number of disk blocks read from all indexes on the table"), prometheus.CounterValue},
+	{newTableStatsDesc("table_idx_blks_hit_total", "Number of buffer hits in all indexes on the table"), prometheus.CounterValue},
+}
+
+func newTableStatsDesc(name, help string) *prometheus.Desc {
+	return newDesc(prometheus.BuildFQName(namespace, subSystemServer, name), help, tableStatsLabels, nil)
+}
+
+func NewTableStatsScraper() Scraper {
+	return &tableStatsScraper{}
+}
+
+type tableStatsScraper struct {
+	baseScraper
+}
+
+// 一个表的统计，values与tableStatsMetrics一一对应，为NULL时不输出.
+type tableStats struct {
+	datname, schemaName, tableName string
+	values                         []sql.NullFloat64
+}
+
+func (tableStatsScraper) Name() string {
+	return "table_stats_scraper"
+}
+
+func (s *tableStatsScraper) Configure(opts ScraperOptions) error {
+	if err := opts.check(supportedOptions{databases: true, tables: true, limits: true}); err != nil {
+		return err
+	}
+
+	if len(opts.Tables.Include) == 0 {
+		return errors.New("tables.include must not be empty, only the listed tables are scraped")
+	}
+
+	s.opts = opts
+
+	return nil
+}
+
+func (s *tableStatsScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+	names, err := s.env.databases(ctx, db, s.opts.Databases)
+	if err != nil {
+		return err
+	}
+
+	// n_mod_since_analyze从V6开始提供
+	modSinceAnalyze := "null::bigint"
+	if ver >= 6 {
+		modSinceAnalyze = "pg_stat_get_mod_since_analyze(c.oid)"
+	}
+
+	statsSql := fmt.Sprintf(tableStatsSql,
+		fmt.Sprintf(tableStatsSelect, modSinceAnalyze, "pg_class")+" union all "+
+			fmt.Sprintf(tableStatsSelect, modSinceAnalyze, "gp_dist_random('pg_class')"),
+		fmt.Sprintf(indexStatsSelect, "pg_index")+" union all "+
+			fmt.Sprintf(indexStatsSelect, "gp_dist_random('pg_index')"))
+
+	errs := make([]error, 0)
+	tables := make([]tableStats, 0)
+
+	for _, dbname := range names {
+		stats, err := s.queryDatabase(ctx, dbname, statsSql)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		tables = append(tables, stats...)
+	}
+
+	sort.SliceStable(tables, func(i, j int) bool {
+		if tables[i].datname != tables[j].datname {
+			return tables[i].datname < tables[j].datname
+		}
+
+		if tables[i].schemaName != tables[j].schemaName {
+			return tables[i].schemaName < tables[j].schemaName
+		}
+
+		return tables[i].tableName < tables[j].tableName
+	})
+
+	guard := s.env.guard(s.Name(), s.opts)
+
+	for _, t := range tables {
+		if !guard.next() {
+			continue
+		}
+
+		labels := guard.labels(t.datname, t.schemaName, t.tableName)
+
+		for i, metric := range tableStatsMetrics {
+			if t.values[i].Valid {
+				ch <- prometheus.MustNewConstMetric(metric.desc, metric.valueType, t.values[i].Float64, labels...)
+			}
+		}
+	}
+
+	return combineErr(errs...)
+}
+
+/**
+* 函数：queryDatabase
+* 功能：查询一个数据库中匹配tables的表，再汇总这些表在master和所有segment上的统计
+ */
+func (s *tableStatsScraper) queryDatabase(ctx context.Context, dbname, statsSql string) ([]tableStats, error) {
+	conn, err := s.env.conns.database(dbname)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.QueryContext(ctx, tableCandidatesSql)
+	logger.Infof("Query Database: %s on %s", tableCandidatesSql, dbname)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	oids := make([]int64, 0)
+	tables := make(map[int64]tableStats)
+
+	for rows.Next() {
+		var oid int64
+		var schemaName, tableName string
+		if err = rows.Scan(&oid, &schemaName, &tableName); err != nil {
+			return nil, err
+		}
+
+		if !s.opts.Tables.allowed(schemaName, tableName) {
+			continue
+		}
+
+		oids = append(oids, oid)
+		tables[oid] = tableStats{datname: dbname, schemaName: schemaName, tableName: tableName}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(oids) == 0 {
+		return nil, nil
+	}
+
+	statsRows, err := conn.QueryContext(ctx, statsSql, pq.Array(oids))
+	logger.Infof("Query Database: %s on %s", statsSql, dbname)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer statsRows.Close()
+
+	stats := make([]tableStats, 0, len(oids))
+
+	for statsRows.Next() {
+		var oid int64
+		values := make([]sql.NullFloat64, len(tableStatsMetrics))
+
+		dest := []interface{}{&oid}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+
+		if err = statsRows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		t, ok := tables[oid]
+		if !ok {
+			continue
+		}
+
+		t.values = values
+		stats = append(stats, t)
+	}
+
+	return stats, statsRows.Err()
+}
@@ -0,0 +1,205 @@
+package collector
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	logger "github.com/prometheus/common/log"
+)
+
+/**
+ * 数据库连接的TLS配置：CA证书、客户端证书和私钥以及校验使用的服务器名称，覆盖连接串中的ssl参数，
+ * 证书文件在每次建立新连接时重新读取，证书轮换后无需重启，证书的过期时间作为指标输出
+ */
+
+var tlsCertificateExpiryDesc = newDesc(
+	prometheus.BuildFQName(namespace, subsystemExporter, "tls_certificate_expiry_timestamp_seconds"),
+	"Expiry time of the certificates used for the database connection",
+	[]string{"certificate", "subject"}, nil,
+)
+
+// 支持的sslmode，与驱动一致.
+var sslModes = map[string]bool{
+	"disable":     true,
+	"require":     true,
+	"verify-ca":   true,
+	"verify-full": true,
+}
+
+// 数据库连接的TLS选项，为空的字段不覆盖连接串.
+type TLSOptions struct {
+	// disable、require、verify-ca或verify-full.
+	SSLMode string `yaml:"sslmode,omitempty"`
+
+	// 校验服务器证书的CA证书文件.
+	CAFile string `yaml:"ca_file,omitempty"`
+
+	// 客户端证书和私钥，私钥文件的权限不能超过0600.
+	CertFile string `yaml:"cert_file,omitempty"`
+	KeyFile  string `yaml:"key_file,omitempty"`
+
+	// 校验服务器证书使用的名称，未配置时使用连接的host，要求sslmode为verify-full.
+	ServerName string `yaml:"server_name,omitempty"`
+}
+
+/**
+* 函数：Validate
+* 功能：校验sslmode以及证书文件是否可以读取和解析，客户端证书已过期时返回错误
+ */
+func (o TLSOptions) Validate() error {
+	if o.SSLMode != "" && !sslModes[o.SSLMode] {
+		return fmt.Errorf("unsupported sslmode %q, supported: disable, require, verify-ca, verify-full", o.SSLMode)
+	}
+
+	if o.SSLMode == "disable" && (o.CAFile != "" || o.CertFile != "" || o.ServerName != "") {
+		return fmt.Errorf("tls files and server_name cannot be used with sslmode disable")
+	}
+
+	if o.ServerName != "" && o.SSLMode != "verify-full" {
+		return fmt.Errorf("server_name requires sslmode verify-full")
+	}
+
+	if (o.CertFile == "") != (o.KeyFile == "") {
+		return fmt.Errorf("cert_file and key_file must be configured together")
+	}
+
+	if o.CAFile != "" {
+		if _, err := readCertificates(o.CAFile); err != nil {
+			return err
+		}
+	}
+
+	if o.CertFile != "" {
+		info, err := os.Stat(o.KeyFile)
+		if err != nil {
+			return fmt.Errorf("read key file failed: %v", err)
+		}
+
+		if info.Mode().Perm()&0077 != 0 {
+			return fmt.Errorf("key file %s must not be accessible by group or others", o.KeyFile)
+		}
+
+		if _, err = tls.LoadX509KeyPair(o.CertFile, o.KeyFile); err != nil {
+			return fmt.Errorf("load client certificate %s failed: %v", o.CertFile, err)
+		}
+
+		certs, err := readCertificates(o.CertFile)
+		if err != nil {
+			return err
+		}
+
+		if time.Now().After(certs[0].NotAfter) {
+			return fmt.Errorf("client certificate %s expired at %v", o.CertFile, certs[0].NotAfter)
+		}
+	}
+
+	return nil
+}
+
+/**
+* 函数：apply
+* 功能：将TLS选项写入连接参数，server_name由openDB处理
+ */
+func (o TLSOptions) apply(params map[string]string) {
+	overrides := map[string]string{
+		"sslmode":     o.SSLMode,
+		"sslrootcert": o.CAFile,
+		"sslcert":     o.CertFile,
+		"sslkey":      o.KeyFile,
+	}
+
+	for k, v := range overrides {
+		if v != "" {
+			params[k] = v
+		}
+	}
+}
+
+/**
+* 函数：collectCertificates
+* 功能：输出CA证书和客户端证书的过期时间，每次抓取时重新读取证书文件
+ */
+func (o TLSOptions) collectCertificates(ch chan<- prometheus.Metric) {
+	files := []struct {
+		certificate string
+		filename    string
+	}{
+		{"ca", o.CAFile},
+		{"client", o.CertFile},
+	}
+
+	for _, f := range files {
+		if f.filename == "" {
+			continue
+		}
+
+		certs, err := readCertificates(f.filename)
+		if err != nil {
+			logger.Errorf("read %s certificate failed, error:%v", f.certificate, err)
+			continue
+		}
+
+		// 客户端证书文件中只输出第一个证书，其余为中间证书
+		if f.certificate == "client" {
+			certs = certs[:1]
+		}
+
+		// 同一subject的多个证书只输出最早的过期时间
+		expiry := make(map[string]time.Time, len(certs))
+		for _, cert := range certs {
+			subject := cert.Subject.String()
+			if t, ok := expiry[subject]; !ok || cert.NotAfter.Before(t) {
+				expiry[subject] = cert.NotAfter
+			}
+		}
+
+		for subject, t := range expiry {
+			ch <- prometheus.MustNewConstMetric(tlsCertificateExpiryDesc, prometheus.GaugeValue,
+				float64(t.Unix()), f.certificate, subject)
+		}
+	}
+}
+
+/**
+* 函数：readCertificates
+* 功能：读取PEM格式的证书文件，文件中没有证书时返回错误
+ */
+func readCertificates(filename string) ([]*x509.Certificate, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("read certificate file failed: %v", err)
+	}
+
+	certs := make([]*x509.Certificate, 0)
+
+	for {
+		var block *pem.Block
+		block, content = pem.Decode(content)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse certificate file %s failed: %v", filename, err)
+		}
+
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificate found in %s", filename)
+	}
+
+	return certs, nil
+}
@@ -0,0 +1,119 @@
+package collector
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+/**
+* 函数：writeCertificate
+* 功能：生成自签名证书和私钥，写入dir下的<name>.crt和<name>.key，私钥文件的权限为0600
+ */
+func writeCertificate(t *testing.T, dir, name string, notAfter time.Time) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    notAfter.Add(-24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+
+	if err = ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return certFile, keyFile
+}
+
+func TestTLSOptionsValidate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hdw_exporter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	caFile, _ := writeCertificate(t, dir, "ca", time.Now().Add(24*time.Hour))
+	certFile, keyFile := writeCertificate(t, dir, "client", time.Now().Add(24*time.Hour))
+	expiredCert, expiredKey := writeCertificate(t, dir, "expired", time.Now().Add(-time.Hour))
+
+	_, openKey := writeCertificate(t, dir, "open", time.Now().Add(24*time.Hour))
+	if err = os.Chmod(openKey, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	notPEM := filepath.Join(dir, "not_pem.crt")
+	if err = ioutil.WriteFile(notPEM, []byte("not a certificate"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		opts TLSOptions
+		err  string
+	}{
+		{name: "empty", opts: TLSOptions{}},
+		{name: "ca file", opts: TLSOptions{SSLMode: "verify-ca", CAFile: caFile}},
+		{name: "client certificate", opts: TLSOptions{SSLMode: "verify-full", CAFile: caFile, CertFile: certFile, KeyFile: keyFile, ServerName: "mdw"}},
+		{name: "unsupported sslmode", opts: TLSOptions{SSLMode: "allow"}, err: `unsupported sslmode "allow"`},
+		{name: "files with sslmode disable", opts: TLSOptions{SSLMode: "disable", CAFile: caFile}, err: "cannot be used with sslmode disable"},
+		{name: "server name without verify-full", opts: TLSOptions{SSLMode: "verify-ca", ServerName: "mdw"}, err: "server_name requires sslmode verify-full"},
+		{name: "missing ca file", opts: TLSOptions{CAFile: filepath.Join(dir, "missing.crt")}, err: "read certificate file failed"},
+		{name: "ca file without certificates", opts: TLSOptions{CAFile: notPEM}, err: "no certificate"},
+		{name: "cert without key", opts: TLSOptions{CertFile: certFile}, err: "cert_file and key_file must be configured together"},
+		{name: "key without cert", opts: TLSOptions{KeyFile: keyFile}, err: "cert_file and key_file must be configured together"},
+		{name: "missing key file", opts: TLSOptions{CertFile: certFile, KeyFile: filepath.Join(dir, "missing.key")}, err: "read key file failed"},
+		{name: "key accessible by others", opts: TLSOptions{CertFile: certFile, KeyFile: openKey}, err: "must not be accessible by group or others"},
+		{name: "key does not match", opts: TLSOptions{CertFile: certFile, KeyFile: expiredKey}, err: "load client certificate"},
+		{name: "expired client certificate", opts: TLSOptions{CertFile: expiredCert, KeyFile: expiredKey}, err: "expired"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.opts.Validate()
+
+			if c.err == "" {
+				if err != nil {
+					t.Errorf("Validate() failed: %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("Validate() error = %v, expected %q", err, c.err)
+			}
+		})
+	}
+}
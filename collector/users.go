@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"database/sql"
 	"github.com/prometheus/client_golang/prometheus"
 	logger "github.com/prometheus/common/log"
@@ -15,14 +16,14 @@ const (
 )
 
 var (
-	usersCountDesc = prometheus.NewDesc(
+	usersCountDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemServer, "users_total_count"),
 		"Total user account number for current hashdata database",
 		nil,
 		nil,
 	)
 
-	usersNameDesc = prometheus.NewDesc(
+	usersNameDesc = newDesc(
 		prometheus.BuildFQName(namespace, subSystemServer, "users_name_list"),
 		"Each user account name for current hashdata database",
 		[]string{"username"},
@@ -31,17 +32,19 @@ var (
 )
 
 func NewUsersScraper() Scraper {
-	return usersScraper{}
+	return &usersScraper{}
 }
 
-type usersScraper struct{}
+type usersScraper struct {
+	baseScraper
+}
 
 func (usersScraper) Name() string {
 	return "users_scraper"
 }
 
-func (usersScraper) Scrape(db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
-	rows, err := db.Query(usersSql)
+func (usersScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, ver int) error {
+	rows, err := db.QueryContext(ctx, usersSql)
 	logger.Infof("Query Database: %s", usersSql)
 
 	if err != nil {
@@ -0,0 +1,403 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/prometheus/client_golang/prometheus"
+	logger "github.com/prometheus/common/log"
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v2"
+	"hdw-exporter/collector"
+)
+
+/**
+ * 采集器的配置文件，定义启用的抓取器以及每个抓取器的选项
+ */
+
+// 未在配置文件中指定连接串时读取的环境变量.
+const dataSourceEnv = "GPDB_DATA_SOURCE_URL"
+
+// 配置文件的顶层结构.
+type Config struct {
+	// 数据库连接串，为空时读取环境变量GPDB_DATA_SOURCE_URL.
+	DataSourceName string `yaml:"data_source_name,omitempty"`
+
+	// 数据库连接的TLS选项，证书文件的相对路径相对于配置文件所在的目录.
+	TLS collector.TLSOptions `yaml:"tls,omitempty"`
+
+	// 每个采集器同时运行的抓取器个数，未配置时为1，即依次运行.
+	Concurrency int `yaml:"concurrency,omitempty"`
+
+	// 所有逐个数据库查询的抓取器共用的数据库过滤列表，与抓取器各自的databases同时生效.
+	Databases collector.DatabaseFilter `yaml:"databases,omitempty"`
+
+	// 输出标签值和明细数据前的脱敏规则，同时作用于所有probe目标.
+	Redaction collector.RedactionOptions `yaml:"redaction,omitempty"`
+
+	Scrapers []ScraperConfig `yaml:"scrapers"`
+
+	// 通过/probe?target=<name>抓取的集群.
+	Targets []TargetConfig `yaml:"targets,omitempty"`
+
+	// 自定义查询文件，相对路径相对于配置文件所在的目录，其中的查询同时作用于所有probe目标.
+	QueriesFile string `yaml:"queries_file,omitempty"`
+
+	// 从QueriesFile读取的查询.
+	customQueries []collector.CustomQuery
+
+	// 来源于命令行参数的选项.
+	flags Flags
+}
+
+// 来源于命令行参数的选项，作用于/metrics使用的连接，其中的安全选项同时作用于所有probe目标.
+type Flags struct {
+	// 覆盖data_source_name的host、port、user、dbname以及密码文件.
+	Connection collector.ConnectionOptions
+
+	// 连接的安全选项.
+	Safety collector.SafetyOptions
+}
+
+// 通过/probe抓取的集群，scrapers未配置时使用顶层的抓取器配置.
+type TargetConfig struct {
+	Name           string `yaml:"name"`
+	DataSourceName string `yaml:"data_source_name"`
+
+	// 密码文件，相对路径相对于配置文件所在的目录，优先于data_source_name中的密码.
+	PasswordFile string `yaml:"password_file,omitempty"`
+
+	// 未配置时使用顶层的TLS选项.
+	TLS *collector.TLSOptions `yaml:"tls,omitempty"`
+
+	Labels   map[string]string `yaml:"labels,omitempty"`
+	Scrapers []ScraperConfig   `yaml:"scrapers,omitempty"`
+}
+
+// 单个抓取器的配置，name为抓取器Name()的返回值.
+type ScraperConfig struct {
+	Name string `yaml:"name"`
+
+	// 未配置时视为启用.
+	Enabled *bool `yaml:"enabled,omitempty"`
+
+	collector.ScraperOptions `yaml:",inline"`
+}
+
+/**
+* 函数：Load
+* 功能：读取并校验配置文件，文件路径为空时返回默认配置，flags为命令行指定的连接选项
+ */
+func Load(filename string, flags Flags) (*Config, error) {
+	cfg := &Config{flags: flags}
+
+	if filename == "" {
+		return cfg, cfg.Validate()
+	}
+
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("read config file %s failed: %v", filename, err)
+	}
+
+	if err = yaml.UnmarshalStrict(content, cfg); err != nil {
+		return nil, fmt.Errorf("parse config file %s failed: %v", filename, err)
+	}
+
+	if cfg.QueriesFile != "" {
+		if !filepath.IsAbs(cfg.QueriesFile) {
+			cfg.QueriesFile = filepath.Join(filepath.Dir(filename), cfg.QueriesFile)
+		}
+
+		if cfg.customQueries, err = loadQueries(cfg.QueriesFile); err != nil {
+			return nil, err
+		}
+	}
+
+	dir := filepath.Dir(filename)
+	resolveTLSFiles(&cfg.TLS, dir)
+
+	for i := range cfg.Targets {
+		if file := cfg.Targets[i].PasswordFile; file != "" && !filepath.IsAbs(file) {
+			cfg.Targets[i].PasswordFile = filepath.Join(dir, file)
+		}
+
+		if cfg.Targets[i].TLS != nil {
+			resolveTLSFiles(cfg.Targets[i].TLS, dir)
+		}
+	}
+
+	if err = cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %v", filename, err)
+	}
+
+	return cfg, nil
+}
+
+/**
+* 函数：DataSource
+* 功能：返回数据库连接串，配置文件中未指定时读取环境变量
+ */
+func (c *Config) DataSource() string {
+	if c.DataSourceName != "" {
+		return c.DataSourceName
+	}
+
+	return os.Getenv(dataSourceEnv)
+}
+
+/**
+* 函数：CollectorOptions
+* 功能：返回/metrics使用的采集器选项
+ */
+func (c *Config) CollectorOptions() collector.CollectorOptions {
+	return collector.CollectorOptions{
+		DataSourceName: c.DataSource(),
+		Connection:     c.flags.Connection,
+		TLS:            c.TLS,
+		Concurrency:    c.Concurrency,
+		Redaction:      c.Redaction,
+		Databases:      c.Databases,
+		Safety:         c.flags.Safety,
+	}
+}
+
+/**
+* 函数：TargetCollectorOptions
+* 功能：返回probe目标使用的采集器选项
+ */
+func (c *Config) TargetCollectorOptions(t TargetConfig) collector.CollectorOptions {
+	return collector.CollectorOptions{
+		DataSourceName: t.DataSourceName,
+		Connection:     collector.ConnectionOptions{PasswordFile: t.PasswordFile},
+		TLS:            c.targetTLS(t),
+		Concurrency:    c.Concurrency,
+		Redaction:      c.Redaction,
+		Databases:      c.Databases,
+		Safety:         c.flags.Safety,
+	}
+}
+
+/**
+* 函数：targetTLS
+* 功能：返回probe目标使用的TLS选项，目标未配置时使用顶层的TLS选项
+ */
+func (c *Config) targetTLS(t TargetConfig) collector.TLSOptions {
+	if t.TLS == nil {
+		return c.TLS
+	}
+
+	return *t.TLS
+}
+
+/**
+* 函数：Validate
+* 功能：校验抓取器名称是否存在且不重复，以及抓取器的选项是否合法
+ */
+func (c *Config) Validate() error {
+	if c.Concurrency < 0 {
+		return fmt.Errorf("concurrency must not be negative")
+	}
+
+	if err := c.Redaction.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Databases.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.flags.Connection.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.TLS.Validate(); err != nil {
+		return fmt.Errorf("tls: %v", err)
+	}
+
+	if _, err := c.BuildScrapers(); err != nil {
+		return err
+	}
+
+	names := make(map[string]bool, len(c.Targets))
+
+	for _, t := range c.Targets {
+		if t.Name == "" {
+			return fmt.Errorf("target name must not be empty")
+		}
+
+		if names[t.Name] {
+			return fmt.Errorf("target %q is configured more than once", t.Name)
+		}
+
+		names[t.Name] = true
+
+		if t.DataSourceName == "" {
+			return fmt.Errorf("target %q: data_source_name must not be empty", t.Name)
+		}
+
+		if err := (collector.ConnectionOptions{PasswordFile: t.PasswordFile}).Validate(); err != nil {
+			return fmt.Errorf("target %q: %v", t.Name, err)
+		}
+
+		if t.TLS != nil {
+			if err := t.TLS.Validate(); err != nil {
+				return fmt.Errorf("target %q: tls: %v", t.Name, err)
+			}
+		}
+
+		for label := range t.Labels {
+			if !model.LabelName(label).IsValid() {
+				return fmt.Errorf("target %q: invalid label name %q", t.Name, label)
+			}
+		}
+
+		scrapers, err := c.BuildTargetScrapers(t)
+		if err != nil {
+			return fmt.Errorf("target %q: %v", t.Name, err)
+		}
+
+		// 与指标自身的标签重名时注册采集器会失败，与/probe中的注册方式相同
+		registerer := prometheus.WrapRegistererWith(prometheus.Labels(t.Labels), prometheus.NewRegistry())
+		if err = registerer.Register(collector.NewScraperDescriber(scrapers)); err != nil {
+			return fmt.Errorf("target %q: labels conflict with exporter metrics: %v", t.Name, err)
+		}
+	}
+
+	return nil
+}
+
+/**
+* 函数：BuildScrapers
+* 功能：按配置创建所有启用的抓取器，未在配置文件中出现的抓取器使用默认启用状态
+ */
+func (c *Config) BuildScrapers() ([]collector.Scraper, error) {
+	return c.buildScrapers(c.Scrapers)
+}
+
+/**
+* 函数：BuildTargetScrapers
+* 功能：创建probe目标启用的抓取器，目标未配置抓取器时使用顶层的抓取器配置
+ */
+func (c *Config) BuildTargetScrapers(t TargetConfig) ([]collector.Scraper, error) {
+	if len(t.Scrapers) == 0 {
+		return c.buildScrapers(c.Scrapers)
+	}
+
+	return c.buildScrapers(t.Scrapers)
+}
+
+/**
+* 函数：buildScrapers
+* 功能：创建内置抓取器以及自定义查询抓取器
+ */
+func (c *Config) buildScrapers(scraperConfigs []ScraperConfig) ([]collector.Scraper, error) {
+	scrapers, err := buildScrapers(scraperConfigs, c.flags.Safety.ReadOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	customScrapers, err := collector.NewCustomQueryScrapers(c.customQueries)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(scrapers, customScrapers...), nil
+}
+
+func buildScrapers(scraperConfigs []ScraperConfig, readOnly bool) ([]collector.Scraper, error) {
+	configs := make(map[string]ScraperConfig, len(scraperConfigs))
+
+	for _, sc := range scraperConfigs {
+		if scraper, _ := collector.NewScraper(sc.Name); scraper == nil {
+			return nil, fmt.Errorf("unknown scraper %q, available scrapers: %v", sc.Name, collector.ScraperNames())
+		}
+
+		if _, ok := configs[sc.Name]; ok {
+			return nil, fmt.Errorf("scraper %q is configured more than once", sc.Name)
+		}
+
+		configs[sc.Name] = sc
+	}
+
+	scrapers := make([]collector.Scraper, 0, len(configs))
+
+	for _, name := range collector.ScraperNames() {
+		scraper, enabled := collector.NewScraper(name)
+
+		sc, configured := configs[name]
+		if configured {
+			if err := configure(scraper, sc.ScraperOptions); err != nil {
+				return nil, fmt.Errorf("scraper %q: %v", name, err)
+			}
+
+			enabled = sc.Enabled == nil || *sc.Enabled
+		}
+
+		if !enabled {
+			continue
+		}
+
+		// 只读模式下默认启用的写操作抓取器直接跳过，配置文件中明确启用时报错
+		if readOnly && collector.WritesDatabase(scraper) {
+			if configured {
+				return nil, fmt.Errorf("scraper %q writes to the database and cannot be enabled in read-only mode", name)
+			}
+
+			logger.Warnf("scraper %s writes to the database, it is disabled in read-only mode", name)
+			continue
+		}
+
+		scrapers = append(scrapers, scraper)
+	}
+
+	return scrapers, nil
+}
+
+func configure(scraper collector.Scraper, opts collector.ScraperOptions) error {
+	if opts.Timeout < 0 || opts.Interval < 0 {
+		return fmt.Errorf("timeout and interval must not be negative")
+	}
+
+	if cs, ok := scraper.(collector.ConfigurableScraper); ok {
+		return cs.Configure(opts)
+	}
+
+	if !opts.IsZero() {
+		return fmt.Errorf("scraper does not support any options")
+	}
+
+	return nil
+}
+
+/**
+* 函数：loadQueries
+* 功能：读取自定义查询文件，查询的校验在创建抓取器时进行
+ */
+func loadQueries(filename string) ([]collector.CustomQuery, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("read queries file %s failed: %v", filename, err)
+	}
+
+	file := collector.CustomQueriesFile{}
+	if err = yaml.UnmarshalStrict(content, &file); err != nil {
+		return nil, fmt.Errorf("parse queries file %s failed: %v", filename, err)
+	}
+
+	return file.Queries, nil
+}
+
+/**
+* 函数：resolveTLSFiles
+* 功能：将证书文件的相对路径转换为相对于配置文件所在目录的路径
+ */
+func resolveTLSFiles(opts *collector.TLSOptions, dir string) {
+	for _, file := range []*string{&opts.CAFile, &opts.CertFile, &opts.KeyFile} {
+		if *file != "" && !filepath.IsAbs(*file) {
+			*file = filepath.Join(dir, *file)
+		}
+	}
+}
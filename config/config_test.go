@@ -0,0 +1,211 @@
+package config
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"hdw-exporter/collector"
+)
+
+// 未在配置文件中出现时默认启用的抓取器.
+func defaultScrapers() []string {
+	names := make([]string, 0)
+	for _, name := range collector.ScraperNames() {
+		if _, enabled := collector.NewScraper(name); enabled {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+func scraperNames(scrapers []collector.Scraper) []string {
+	names := make([]string, 0, len(scrapers))
+	for _, scraper := range scrapers {
+		names = append(names, scraper.Name())
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestBuildScrapers(t *testing.T) {
+	disabled, enabled := false, true
+
+	cases := []struct {
+		name     string
+		scrapers []ScraperConfig
+		include  []string
+		exclude  []string
+		err      string
+	}{
+		{
+			name:    "defaults",
+			include: defaultScrapers(),
+			exclude: []string{"users_scraper"},
+		},
+		{
+			name:     "disable a default scraper",
+			scrapers: []ScraperConfig{{Name: "locks_scraper", Enabled: &disabled}},
+			exclude:  []string{"locks_scraper"},
+		},
+		{
+			name:     "listed scraper is enabled",
+			scrapers: []ScraperConfig{{Name: "users_scraper"}},
+			include:  []string{"users_scraper", "locks_scraper"},
+		},
+		{
+			name:     "explicitly enabled",
+			scrapers: []ScraperConfig{{Name: "users_scraper", Enabled: &enabled}, {Name: "locks_scraper", Enabled: &enabled}},
+			include:  []string{"users_scraper", "locks_scraper"},
+		},
+		{
+			name:     "unknown scraper",
+			scrapers: []ScraperConfig{{Name: "no_such_scraper"}},
+			err:      `unknown scraper "no_such_scraper"`,
+		},
+		{
+			name:     "unknown disabled scraper",
+			scrapers: []ScraperConfig{{Name: "no_such_scraper", Enabled: &disabled}},
+			err:      `unknown scraper "no_such_scraper"`,
+		},
+		{
+			name:     "duplicate scraper",
+			scrapers: []ScraperConfig{{Name: "users_scraper"}, {Name: "users_scraper", Enabled: &disabled}},
+			err:      `scraper "users_scraper" is configured more than once`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			scrapers, err := (&Config{Scrapers: c.scrapers}).BuildScrapers()
+
+			if c.err != "" {
+				if err == nil || !strings.Contains(err.Error(), c.err) {
+					t.Fatalf("BuildScrapers() error = %v, expected %q", err, c.err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("BuildScrapers() failed: %v", err)
+			}
+
+			names := scraperNames(scrapers)
+
+			for _, name := range c.include {
+				if !contains(names, name) {
+					t.Errorf("scraper %s is not enabled, enabled scrapers: %v", name, names)
+				}
+			}
+
+			for _, name := range c.exclude {
+				if contains(names, name) {
+					t.Errorf("scraper %s is enabled, enabled scrapers: %v", name, names)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildScrapersReadOnly(t *testing.T) {
+	disabled := false
+
+	cases := []struct {
+		name     string
+		scrapers []ScraperConfig
+		readOnly bool
+		enabled  bool
+		err      string
+	}{
+		{"read-write", []ScraperConfig{{Name: "DataSkewScraper"}}, false, true, ""},
+		{"read-only default", nil, true, false, ""},
+		{"read-only disabled", []ScraperConfig{{Name: "DataSkewScraper", Enabled: &disabled}}, true, false, ""},
+		{"read-only enabled", []ScraperConfig{{Name: "DataSkewScraper"}}, true, false, "cannot be enabled in read-only mode"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			scrapers, err := buildScrapers(c.scrapers, c.readOnly)
+
+			if c.err != "" {
+				if err == nil || !strings.Contains(err.Error(), c.err) {
+					t.Fatalf("buildScrapers() error = %v, expected %q", err, c.err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("buildScrapers() failed: %v", err)
+			}
+
+			if enabled := contains(scraperNames(scrapers), "DataSkewScraper"); enabled != c.enabled {
+				t.Errorf("DataSkewScraper enabled = %v, expected %v", enabled, c.enabled)
+			}
+
+			if c.readOnly {
+				for _, scraper := range scrapers {
+					if collector.WritesDatabase(scraper) {
+						t.Errorf("scraper %s writes to the database in read-only mode", scraper.Name())
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestValidateTargetLabels(t *testing.T) {
+	queries := []collector.CustomQuery{{
+		Name:   "etl",
+		SQL:    "select job, lag from etl_status",
+		Labels: []string{"job_name"},
+		Values: []collector.CustomValue{{Column: "lag"}},
+	}}
+
+	cases := []struct {
+		name   string
+		labels map[string]string
+		err    string
+	}{
+		{"cluster label", map[string]string{"cluster": "dev1"}, ""},
+		{"invalid label", map[string]string{"cluster-name": "dev1"}, "invalid label name"},
+		{"builtin metric label", map[string]string{"datname": "postgres"}, "labels conflict with exporter metrics"},
+		{"exporter metric label", map[string]string{"scraper": "probe"}, "labels conflict with exporter metrics"},
+		{"custom query label", map[string]string{"job_name": "nightly"}, "labels conflict with exporter metrics"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cfg := &Config{
+				Targets:       []TargetConfig{{Name: "dev1", DataSourceName: "host=mdw", Labels: c.labels}},
+				customQueries: queries,
+			}
+
+			err := cfg.Validate()
+			if c.err == "" {
+				if err != nil {
+					t.Errorf("Validate() failed: %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("Validate() = %v, expected error containing %q", err, c.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"hdw-exporter/collector"
+	"net/http"
+	"strconv"
+	"strings"
+
+	logger "github.com/prometheus/common/log"
+)
+
+/**
+ * 明细数据查询接口：/api/v1/<dataset>?target=<name>&offset=<n>&limit=<n>&<field>=<value>
+ * 返回抓取器最近一次查询到的明细行，除target、offset、limit外的参数均作为字段过滤条件
+ */
+
+const (
+	inspectPathPrefix = "/api/v1/"
+	defaultPageLimit  = 100
+)
+
+type inspector struct {
+	collector *collector.HdwCollector
+	prober    *prober
+}
+
+func newInspector(hdwCollector *collector.HdwCollector, prober *prober) *inspector {
+	return &inspector{collector: hdwCollector, prober: prober}
+}
+
+func (i *inspector) handleInspect(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "only GET is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	dataset := strings.TrimPrefix(req.URL.Path, inspectPathPrefix)
+	if !validDataset(dataset) {
+		http.Error(w, fmt.Sprintf("unknown dataset %q, available datasets: %v", dataset, collector.Datasets), http.StatusNotFound)
+		return
+	}
+
+	query := req.URL.Query()
+
+	details := i.collector.Details()
+	if name := query.Get("target"); name != "" {
+		hdwCollector, _, ok := i.prober.target(name)
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown target %q", name), http.StatusNotFound)
+			return
+		}
+
+		details = hdwCollector.Details()
+	}
+
+	offset, err := intParam(query.Get("offset"), 0)
+	if err != nil || offset < 0 {
+		http.Error(w, "offset must be a non-negative integer", http.StatusBadRequest)
+		return
+	}
+
+	limit, err := intParam(query.Get("limit"), defaultPageLimit)
+	if err != nil || limit < 0 {
+		http.Error(w, "limit must be a non-negative integer", http.StatusBadRequest)
+		return
+	}
+
+	filter := make(map[string]string)
+	for field, values := range query {
+		if field == "target" || field == "offset" || field == "limit" {
+			continue
+		}
+
+		filter[field] = values[0]
+	}
+
+	page, ok := details.Query(dataset, filter, offset, limit)
+	if !ok {
+		http.Error(w, fmt.Sprintf("dataset %q has not been scraped yet, check that its scraper is enabled", dataset), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(page); err != nil {
+		logger.Errorf("write dataset %s failed, error:%v", dataset, err)
+	}
+}
+
+func validDataset(dataset string) bool {
+	for _, d := range collector.Datasets {
+		if d == dataset {
+			return true
+		}
+	}
+
+	return false
+}
+
+func intParam(value string, def int) (int, error) {
+	if value == "" {
+		return def, nil
+	}
+
+	return strconv.Atoi(value)
+}
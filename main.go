@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
 	"hdw-exporter/collector"
+	"hdw-exporter/config"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -14,33 +18,18 @@ var (
 	listenAddress         = kingpin.Flag("web.listen-address", "web endpoint").Default("0.0.0.0:9297").String()
 	metricPath            = kingpin.Flag("web.telemetry-path", "Path under which to expose metrics.").Default("/metrics").String()
 	disableDefaultMetrics = kingpin.Flag("disableDefaultMetrics", "do not report default metrics(go metrics and process metrics)").Default("true").Bool()
+	timeoutOffset         = kingpin.Flag("web.timeout-offset", "Offset to subtract from the timeout sent by Prometheus, leaving time to return the metrics.").Default("500ms").Duration()
+	webConfigFile         = kingpin.Flag("web.config.file", "Path to the YAML file enabling TLS and authentication for all endpoints.").Default("").String()
+	configFile            = kingpin.Flag("config.file", "Path to the YAML file listing the scrapers to enable and their options.").Default("").String()
+	dbHost                = kingpin.Flag("db.host", "Database host, overrides the host of the data source name.").Default("").String()
+	dbPort                = kingpin.Flag("db.port", "Database port, overrides the port of the data source name.").Default("").String()
+	dbUser                = kingpin.Flag("db.user", "Database user, overrides the user of the data source name.").Default("").String()
+	dbName                = kingpin.Flag("db.name", "Database name, overrides the dbname of the data source name.").Default("").String()
+	dbPasswordFile        = kingpin.Flag("db.password-file", "File containing the database password, re-read on every new connection so that rotated secrets are picked up.").Default("").String()
+	readOnly              = kingpin.Flag("safety.read-only", "Open every connection with default_transaction_read_only=on and refuse to enable scrapers that write to the database.").Default("false").Bool()
+	statementTimeout      = kingpin.Flag("safety.statement-timeout", "statement_timeout of every connection in read-only mode, 0 to leave it unset.").Default("30s").Duration()
 )
 
-var scrapers = map[collector.Scraper]bool{
-	collector.NewClusterStateScraper():  true,
-	collector.NewSegmentScraper():       true,
-	collector.NewDatabaseSizeScraper():  true,
-	collector.NewLocksScraper():         true,
-	collector.NewConnectionsScraper():   true,
-	collector.NewMaxConnScraper():       true,
-	collector.NewConnDetailScraper():    true,
-	collector.NewUsersScraper():         false,
-	collector.NewBgWriterStateScraper(): false,
-
-	collector.NewSystemScraper():        false,
-	collector.NewQueryScraper():         false,
-	collector.NewDynamicMemoryScraper(): false,
-	collector.NewDiskScraper():          false,
-
-	collector.NewActivityScraper():      true,
-	collector.NewSessionMemoryScraper(): true,
-	collector.NewbloatScraper():         true,
-	collector.NewDataSkewScraper():      true,
-	collector.NewMasterLogScraper():     true,
-}
-
-var gathers prometheus.Gatherers
-
 func main() {
 	kingpin.Version("1.1.1")
 	kingpin.HelpFlag.Short('h')
@@ -48,42 +37,107 @@ func main() {
 	logger.AddFlags(kingpin.CommandLine)
 	kingpin.Parse()
 
-	metricsHandleFunc := newHandler(*disableDefaultMetrics, scrapers)
+	safety := collector.SafetyOptions{ReadOnly: *readOnly, StatementTimeout: *statementTimeout}
+
+	flags := config.Flags{
+		Connection: collector.ConnectionOptions{
+			Host:         *dbHost,
+			Port:         *dbPort,
+			User:         *dbUser,
+			Database:     *dbName,
+			PasswordFile: *dbPasswordFile,
+		},
+		Safety: safety,
+	}
+
+	webCfg, err := loadWebConfig(*webConfigFile)
+	if err != nil {
+		logger.Fatalf("load web configuration failed, error:%v", err)
+	}
+
+	cfg, err := config.Load(*configFile, flags)
+	if err != nil {
+		logger.Fatalf("load configuration failed, error:%v", err)
+	}
+
+	scrapers, err := cfg.BuildScrapers()
+	if err != nil {
+		logger.Fatalf("build scrapers failed, error:%v", err)
+	}
+
+	targetScrapers, err := buildTargetScrapers(cfg)
+	if err != nil {
+		logger.Fatalf("build scrapers failed, error:%v", err)
+	}
+
+	hdwCollector := collector.NewCollector(cfg.CollectorOptions(), scrapers)
+
+	prober := newProber()
+	prober.update(cfg, targetScrapers)
+
+	reloader := newReloader(*configFile, flags, hdwCollector, prober)
+	go reloader.watchSignals()
+
+	metricsHandleFunc := newHandler(*disableDefaultMetrics, hdwCollector, reloader)
 
 	mux := http.NewServeMux()
 
 	mux.HandleFunc(*metricPath, metricsHandleFunc)
+	mux.HandleFunc("/probe", prober.handleProbe)
+	mux.HandleFunc("/-/reload", reloader.handleReload)
+	mux.HandleFunc(inspectPathPrefix, newInspector(hdwCollector, prober).handleInspect)
 
-	logger.Warnf("HDW exporter is starting and will listening on : %s", *listenAddress)
+	logger.Warnf("HDW exporter is starting in %s mode and will listening on : %s", safety, *listenAddress)
 
-	logger.Error(http.ListenAndServe(*listenAddress, mux).Error())
+	logger.Error(listenAndServe(*listenAddress, webCfg, mux).Error())
 }
 
-func newHandler(disableDefaultMetrics bool, scrapers map[collector.Scraper]bool) http.HandlerFunc {
+func newHandler(disableDefaultMetrics bool, hdwCollector *collector.HdwCollector, reloader *reloader) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := scrapeContext(r)
+		defer cancel()
 
-	registry := prometheus.NewRegistry()
+		// 每次请求使用新的registry，使抓取器能感知请求的取消和超时
+		registry := prometheus.NewRegistry()
 
-	enabledScrapers := make([]collector.Scraper, 0, 16)
+		registry.MustRegister(hdwCollector.WithContext(ctx), reloader)
 
-	for scraper, enable := range scrapers {
-		if enable {
-			enabledScrapers = append(enabledScrapers, scraper)
+		var gathers prometheus.Gatherers
+		if disableDefaultMetrics {
+			gathers = prometheus.Gatherers{registry}
+		} else {
+			gathers = prometheus.Gatherers{registry, prometheus.DefaultGatherer}
 		}
-	}
 
-	hdwCollector := collector.NewCollector(enabledScrapers)
+		handler := promhttp.HandlerFor(gathers, promhttp.HandlerOpts{
+			ErrorHandling: promhttp.ContinueOnError,
+		})
 
-	registry.MustRegister(hdwCollector)
+		handler.ServeHTTP(w, r)
+	}
+}
 
-	if disableDefaultMetrics {
-		gathers = prometheus.Gatherers{registry}
-	} else {
-		gathers = prometheus.Gatherers{registry, prometheus.DefaultGatherer}
+/**
+* 函数：scrapeContext
+* 功能：根据请求头X-Prometheus-Scrape-Timeout-Seconds生成抓取使用的context，预留timeoutOffset用于返回结果
+ */
+func scrapeContext(r *http.Request) (context.Context, context.CancelFunc) {
+	header := r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds")
+	if header == "" {
+		return context.WithCancel(r.Context())
 	}
 
-	handler := promhttp.HandlerFor(gathers, promhttp.HandlerOpts{
-		ErrorHandling: promhttp.ContinueOnError,
-	})
+	seconds, err := strconv.ParseFloat(header, 64)
+	if err != nil {
+		logger.Errorf("parse X-Prometheus-Scrape-Timeout-Seconds header %q failed, error:%v", header, err)
+		return context.WithCancel(r.Context())
+	}
+
+	timeout := time.Duration(seconds*float64(time.Second)) - *timeoutOffset
+	if timeout <= 0 {
+		timeout = time.Duration(seconds * float64(time.Second))
+	}
 
-	return handler.ServeHTTP
+	return context.WithTimeout(r.Context(), timeout)
 }
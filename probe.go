@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"hdw-exporter/collector"
+	"hdw-exporter/config"
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+/**
+ * 多集群抓取：/probe?target=<name>，每个目标拥有独立的采集器和数据库连接
+ */
+
+type probeTarget struct {
+	collector *collector.HdwCollector
+	labels    prometheus.Labels
+}
+
+type prober struct {
+	mu      sync.RWMutex
+	targets map[string]*probeTarget
+}
+
+func newProber() *prober {
+	return &prober{targets: make(map[string]*probeTarget)}
+}
+
+/**
+* 函数：buildTargetScrapers
+* 功能：为配置中的每个目标创建抓取器，任一目标失败时返回错误
+ */
+func buildTargetScrapers(cfg *config.Config) (map[string][]collector.Scraper, error) {
+	scrapers := make(map[string][]collector.Scraper, len(cfg.Targets))
+
+	for _, t := range cfg.Targets {
+		targetScrapers, err := cfg.BuildTargetScrapers(t)
+		if err != nil {
+			return nil, fmt.Errorf("target %q: %v", t.Name, err)
+		}
+
+		scrapers[t.Name] = targetScrapers
+	}
+
+	return scrapers, nil
+}
+
+/**
+* 函数：update
+* 功能：按配置更新目标，已有目标保留采集器（及其计数器），移除的目标关闭数据库连接
+ */
+func (p *prober) update(cfg *config.Config, scrapers map[string][]collector.Scraper) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	targets := make(map[string]*probeTarget, len(cfg.Targets))
+
+	for _, t := range cfg.Targets {
+		target, ok := p.targets[t.Name]
+		if ok {
+			target.collector.Reload(cfg.TargetCollectorOptions(t), scrapers[t.Name])
+		} else {
+			target = &probeTarget{collector: collector.NewCollector(cfg.TargetCollectorOptions(t), scrapers[t.Name])}
+		}
+
+		target.labels = prometheus.Labels(t.Labels)
+		targets[t.Name] = target
+	}
+
+	for name, target := range p.targets {
+		if _, ok := targets[name]; !ok {
+			target.collector.Close()
+		}
+	}
+
+	p.targets = targets
+}
+
+/**
+* 函数：target
+* 功能：根据名称返回目标的采集器以及标签的副本，重新加载配置时会替换标签，因此在持有锁时复制
+ */
+func (p *prober) target(name string) (*collector.HdwCollector, prometheus.Labels, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	target, ok := p.targets[name]
+	if !ok {
+		return nil, nil, false
+	}
+
+	labels := make(prometheus.Labels, len(target.labels))
+	for k, v := range target.labels {
+		labels[k] = v
+	}
+
+	return target.collector, labels, true
+}
+
+func (p *prober) handleProbe(w http.ResponseWriter, req *http.Request) {
+	name := req.URL.Query().Get("target")
+	if name == "" {
+		http.Error(w, "target parameter is missing", http.StatusBadRequest)
+		return
+	}
+
+	hdwCollector, labels, ok := p.target(name)
+
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown target %q", name), http.StatusNotFound)
+		return
+	}
+
+	ctx, cancel := scrapeContext(req)
+	defer cancel()
+
+	registry := prometheus.NewRegistry()
+	if err := prometheus.WrapRegistererWith(labels, registry).Register(hdwCollector.WithContext(ctx)); err != nil {
+		http.Error(w, fmt.Sprintf("register collector of target %q failed: %v", name, err), http.StatusInternalServerError)
+		return
+	}
+
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+		ErrorHandling: promhttp.ContinueOnError,
+	}).ServeHTTP(w, req)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"hdw-exporter/collector"
+)
+
+func TestHandleProbe(t *testing.T) {
+	hdwCollector := collector.NewCollector(collector.CollectorOptions{DataSourceName: "host=mdw"}, nil)
+	t.Cleanup(hdwCollector.Close)
+
+	p := newProber()
+	// 标签与指标自身的标签重名，正常情况下在校验配置时被拒绝
+	p.targets["dev1"] = &probeTarget{collector: hdwCollector, labels: prometheus.Labels{"datname": "postgres"}}
+
+	cases := []struct {
+		name   string
+		query  string
+		status int
+		body   string
+	}{
+		{"missing target", "", http.StatusBadRequest, "target parameter is missing"},
+		{"unknown target", "?target=test1", http.StatusNotFound, `unknown target "test1"`},
+		{"conflicting labels", "?target=dev1", http.StatusInternalServerError, `register collector of target "dev1" failed`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			p.handleProbe(rec, httptest.NewRequest(http.MethodGet, "/probe"+c.query, nil))
+
+			if rec.Code != c.status {
+				t.Errorf("status = %d, expected %d", rec.Code, c.status)
+			}
+
+			if !strings.Contains(rec.Body.String(), c.body) {
+				t.Errorf("body = %q, expected to contain %q", rec.Body.String(), c.body)
+			}
+		})
+	}
+}
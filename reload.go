@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"hdw-exporter/collector"
+	"hdw-exporter/config"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/prometheus/client_golang/prometheus"
+	logger "github.com/prometheus/common/log"
+)
+
+/**
+ * 配置文件的热加载，通过SIGHUP信号或者POST /-/reload触发，同时更新/metrics和/probe的采集器
+ */
+
+type reloader struct {
+	mu sync.Mutex
+
+	configFile string
+	flags      config.Flags
+	collector  *collector.HdwCollector
+	prober     *prober
+
+	lastSuccess     prometheus.Gauge
+	lastSuccessTime prometheus.Gauge
+}
+
+func newReloader(configFile string, flags config.Flags, hdwCollector *collector.HdwCollector, prober *prober) *reloader {
+	r := &reloader{
+		configFile: configFile,
+		flags:      flags,
+		collector:  hdwCollector,
+		prober:     prober,
+		lastSuccess: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: "hashdata",
+				Subsystem: "exporter",
+				Name:      "config_last_reload_successful",
+				Help:      "Whether the last configuration reload attempt was successful",
+			},
+		),
+		lastSuccessTime: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: "hashdata",
+				Subsystem: "exporter",
+				Name:      "config_last_reload_success_timestamp_seconds",
+				Help:      "Timestamp of the last successful configuration reload",
+			},
+		),
+	}
+
+	r.lastSuccess.Set(1)
+	r.lastSuccessTime.SetToCurrentTime()
+
+	return r
+}
+
+/**
+* 函数：reload
+* 功能：重新读取配置文件并替换采集器的连接串和抓取器，配置不合法时保留原有配置
+ */
+func (r *reloader) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg, err := config.Load(r.configFile, r.flags)
+	if err != nil {
+		r.lastSuccess.Set(0)
+		return err
+	}
+
+	scrapers, err := cfg.BuildScrapers()
+	if err != nil {
+		r.lastSuccess.Set(0)
+		return err
+	}
+
+	targetScrapers, err := buildTargetScrapers(cfg)
+	if err != nil {
+		r.lastSuccess.Set(0)
+		return err
+	}
+
+	r.collector.Reload(cfg.CollectorOptions(), scrapers)
+	r.prober.update(cfg, targetScrapers)
+
+	r.lastSuccess.Set(1)
+	r.lastSuccessTime.SetToCurrentTime()
+
+	logger.Warnf("configuration reloaded, %d scrapers enabled, %d probe targets", len(scrapers), len(cfg.Targets))
+
+	return nil
+}
+
+/**
+* 函数：watchSignals
+* 功能：收到SIGHUP信号时重新加载配置
+ */
+func (r *reloader) watchSignals() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	for range hup {
+		if err := r.reload(); err != nil {
+			logger.Errorf("reload configuration failed, error:%v", err)
+		}
+	}
+}
+
+func (r *reloader) handleReload(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost && req.Method != http.MethodPut {
+		w.Header().Set("Allow", "POST, PUT")
+		http.Error(w, "Only POST or PUT requests allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.reload(); err != nil {
+		logger.Errorf("reload configuration failed, error:%v", err)
+		http.Error(w, fmt.Sprintf("failed to reload config: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	_, _ = fmt.Fprintln(w, "configuration reloaded")
+}
+
+func (r *reloader) Describe(ch chan<- *prometheus.Desc) {
+	r.lastSuccess.Describe(ch)
+	r.lastSuccessTime.Describe(ch)
+}
+
+func (r *reloader) Collect(ch chan<- prometheus.Metric) {
+	r.lastSuccess.Collect(ch)
+	r.lastSuccessTime.Collect(ch)
+}
@@ -5,6 +5,7 @@ import (
 	"container/list"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 )
 
@@ -14,6 +15,7 @@ type taskInfo struct {
 }
 
 type StopWatch struct {
+	mu              sync.Mutex
 	id              string
 	latestTaskName  string
 	taskList        *list.List
@@ -27,6 +29,9 @@ func New(id string) *StopWatch {
 }
 
 func (w *StopWatch) Start(taskName string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
 	if taskName == "" {
 		return errors.New("task name must not be empty")
 	}
@@ -50,21 +55,33 @@ func (w *StopWatch) MustStart(taskName string) {
 }
 
 func (w *StopWatch) Stop() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
 	if w.latestTaskName == "" {
 		return errors.New("can not stop StopWatch: it's not running")
 	}
 
-	elapsed := time.Since(w.latestStartTime).Nanoseconds()
-
-	w.totalElapsed += elapsed
-	lastTask := taskInfo{taskName: w.latestTaskName, taskElapsed: elapsed}
-	w.taskList.PushBack(lastTask)
-	w.taskCnt++
+	w.addTask(w.latestTaskName, time.Since(w.latestStartTime).Nanoseconds())
 	w.latestTaskName = ""
 
 	return nil
 }
 
+// Record adds a task timed by the caller, safe for tasks running in parallel.
+func (w *StopWatch) Record(taskName string, elapsed time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.addTask(taskName, elapsed.Nanoseconds())
+}
+
+func (w *StopWatch) addTask(taskName string, elapsed int64) {
+	w.totalElapsed += elapsed
+	w.taskList.PushBack(taskInfo{taskName: taskName, taskElapsed: elapsed})
+	w.taskCnt++
+}
+
 func (w *StopWatch) MustStop() {
 	if err := w.Stop(); err != nil {
 		panic(err)
@@ -72,13 +89,23 @@ func (w *StopWatch) MustStop() {
 }
 
 func (w *StopWatch) ShortSummary() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.shortSummary()
+}
+
+func (w *StopWatch) shortSummary() string {
 	return fmt.Sprintf("StopWatch '"+w.id+"': running time (ms) = %d\n", w.totalElapsed/1000000)
 }
 
 func (w *StopWatch) PrettyPrint() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
 	var buf bytes.Buffer
 
-	buf.WriteString(w.ShortSummary())
+	buf.WriteString(w.shortSummary())
 
 	buf.WriteString("-----------------------------------------\n")
 	buf.WriteString("ms        %         Task name\n")
@@ -100,6 +127,9 @@ func (w *StopWatch) PrettyPrint() string {
 }
 
 func (w *StopWatch) Clear() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
 	w.taskList = list.New()
 	w.totalElapsed = 0
 	w.latestTaskName = ""
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	logger "github.com/prometheus/common/log"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v2"
+)
+
+/**
+ * Web配置文件：为exporter提供的所有接口启用HTTPS、基于bcrypt的basic auth以及bearer token认证，
+ * 证书和token文件变化后自动重新读取，无需重启
+ */
+
+// 检查证书文件是否变化的最小间隔.
+const certificateCheckInterval = 10 * time.Second
+
+// 用户名不存在时用于比较的bcrypt哈希，使响应时间与密码错误时相同，避免通过耗时判断用户是否存在.
+const dummyPasswordHash = "$2a$10$P.62RiWDdgtocfMRe8AhKObNZBBv6jwfFOrv89OFeg2CgbeEZb6Ce"
+
+// Web配置文件的顶层结构.
+type webConfig struct {
+	TLSServerConfig *tlsServerConfig `yaml:"tls_server_config,omitempty"`
+
+	// 用户名到bcrypt哈希的映射.
+	BasicAuthUsers map[string]string `yaml:"basic_auth_users,omitempty"`
+
+	// 允许的bearer token以及包含token的文件，文件在每次认证时重新读取.
+	BearerTokens     []string `yaml:"bearer_tokens,omitempty"`
+	BearerTokenFiles []string `yaml:"bearer_token_files,omitempty"`
+}
+
+// HTTPS的证书配置.
+type tlsServerConfig struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+
+	// 配置后要求客户端提供由该CA签发的证书.
+	ClientCAFile string `yaml:"client_ca_file,omitempty"`
+}
+
+/**
+* 函数：loadWebConfig
+* 功能：读取并校验Web配置文件，文件路径为空时返回不启用HTTPS和认证的配置
+ */
+func loadWebConfig(filename string) (*webConfig, error) {
+	cfg := &webConfig{}
+
+	if filename == "" {
+		return cfg, nil
+	}
+
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("read web config file %s failed: %v", filename, err)
+	}
+
+	if err = yaml.UnmarshalStrict(content, cfg); err != nil {
+		return nil, fmt.Errorf("parse web config file %s failed: %v", filename, err)
+	}
+
+	// 相对路径相对于Web配置文件所在的目录
+	files := make([]*string, 0)
+	if t := cfg.TLSServerConfig; t != nil {
+		files = append(files, &t.CertFile, &t.KeyFile, &t.ClientCAFile)
+	}
+
+	for i := range cfg.BearerTokenFiles {
+		files = append(files, &cfg.BearerTokenFiles[i])
+	}
+
+	for _, file := range files {
+		if *file != "" && !filepath.IsAbs(*file) {
+			*file = filepath.Join(filepath.Dir(filename), *file)
+		}
+	}
+
+	if err = cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid web config file %s: %v", filename, err)
+	}
+
+	return cfg, nil
+}
+
+/**
+* 函数：validate
+* 功能：校验证书是否可以加载、bcrypt哈希是否合法以及token文件是否可以读取
+ */
+func (c *webConfig) validate() error {
+	if t := c.TLSServerConfig; t != nil {
+		if t.CertFile == "" || t.KeyFile == "" {
+			return fmt.Errorf("tls_server_config: cert_file and key_file must not be empty")
+		}
+
+		if _, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile); err != nil {
+			return fmt.Errorf("tls_server_config: load certificate failed: %v", err)
+		}
+
+		if t.ClientCAFile != "" {
+			if _, err := loadCertPool(t.ClientCAFile); err != nil {
+				return fmt.Errorf("tls_server_config: %v", err)
+			}
+		}
+	}
+
+	for user, hash := range c.BasicAuthUsers {
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return fmt.Errorf("basic_auth_users: invalid bcrypt hash of user %q: %v", user, err)
+		}
+	}
+
+	for _, token := range c.BearerTokens {
+		if token == "" {
+			return fmt.Errorf("bearer_tokens: token must not be empty")
+		}
+	}
+
+	for _, file := range c.BearerTokenFiles {
+		if _, err := readToken(file); err != nil {
+			return fmt.Errorf("bearer_token_files: %v", err)
+		}
+	}
+
+	return nil
+}
+
+/**
+* 函数：authEnabled
+* 功能：是否配置了basic auth用户或者bearer token
+ */
+func (c *webConfig) authEnabled() bool {
+	return len(c.BasicAuthUsers) > 0 || len(c.BearerTokens) > 0 || len(c.BearerTokenFiles) > 0
+}
+
+// 对请求进行认证的http.Handler.
+type authHandler struct {
+	cfg     *webConfig
+	handler http.Handler
+
+	// 认证成功的用户名和密码哈希，避免每次请求都计算bcrypt.
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+/**
+* 函数：newAuthHandler
+* 功能：返回对所有请求进行认证的handler，未配置用户和token时直接返回handler
+ */
+func newAuthHandler(cfg *webConfig, handler http.Handler) http.Handler {
+	if !cfg.authEnabled() {
+		return handler
+	}
+
+	return &authHandler{cfg: cfg, handler: handler, cache: make(map[string]string)}
+}
+
+func (h *authHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.authenticated(r) {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+
+	if len(h.cfg.BasicAuthUsers) > 0 {
+		w.Header().Set("WWW-Authenticate", `Basic realm="hdw_exporter"`)
+	}
+
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+/**
+* 函数：authenticated
+* 功能：检查请求的basic auth用户名密码或者bearer token是否合法
+ */
+func (h *authHandler) authenticated(r *http.Request) bool {
+	if user, password, ok := r.BasicAuth(); ok {
+		return h.checkPassword(user, password)
+	}
+
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return false
+	}
+
+	return h.checkToken(strings.TrimSpace(strings.TrimPrefix(header, "Bearer ")))
+}
+
+func (h *authHandler) checkPassword(user, password string) bool {
+	hash, ok := h.cfg.BasicAuthUsers[user]
+	if !ok {
+		_ = bcrypt.CompareHashAndPassword([]byte(dummyPasswordHash), []byte(password))
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(password))
+	key := fmt.Sprintf("%x", sum)
+
+	h.mu.Lock()
+	cached, ok := h.cache[user]
+	h.mu.Unlock()
+
+	if ok && subtle.ConstantTimeCompare([]byte(cached), []byte(key)) == 1 {
+		return true
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return false
+	}
+
+	h.mu.Lock()
+	h.cache[user] = key
+	h.mu.Unlock()
+
+	return true
+}
+
+func (h *authHandler) checkToken(token string) bool {
+	if token == "" {
+		return false
+	}
+
+	tokens := append([]string{}, h.cfg.BearerTokens...)
+
+	for _, file := range h.cfg.BearerTokenFiles {
+		t, err := readToken(file)
+		if err != nil {
+			logger.Errorf("read bearer token file failed, error:%v", err)
+			continue
+		}
+
+		tokens = append(tokens, t)
+	}
+
+	for _, t := range tokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+/**
+* 函数：readToken
+* 功能：读取token文件，去掉首尾的空白字符
+ */
+func readToken(filename string) (string, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+
+	token := strings.TrimSpace(string(content))
+	if token == "" {
+		return "", fmt.Errorf("token file %s is empty", filename)
+	}
+
+	return token, nil
+}
+
+// 证书文件变化后重新加载证书.
+type certificateReloader struct {
+	certFile string
+	keyFile  string
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+/**
+* 函数：getCertificate
+* 功能：用于tls.Config.GetCertificate，证书或私钥文件的修改时间变化时重新加载，加载失败时继续使用原来的证书
+ */
+func (r *certificateReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cert != nil && time.Since(r.lastCheck) < certificateCheckInterval {
+		return r.cert, nil
+	}
+
+	r.lastCheck = time.Now()
+
+	modTime, err := latestModTime(r.certFile, r.keyFile)
+	if err != nil {
+		if r.cert != nil {
+			logger.Errorf("check web certificate failed, keep using the loaded certificate, error:%v", err)
+			return r.cert, nil
+		}
+
+		return nil, err
+	}
+
+	if r.cert != nil && modTime.Equal(r.modTime) {
+		return r.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		if r.cert != nil {
+			logger.Errorf("reload web certificate failed, keep using the loaded certificate, error:%v", err)
+			return r.cert, nil
+		}
+
+		return nil, err
+	}
+
+	if r.cert != nil {
+		logger.Infof("web certificate %s reloaded", r.certFile)
+	}
+
+	r.cert = &cert
+	r.modTime = modTime
+
+	return r.cert, nil
+}
+
+func latestModTime(files ...string) (time.Time, error) {
+	var latest time.Time
+
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return latest, err
+		}
+
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
+
+/**
+* 函数：loadCertPool
+* 功能：读取PEM格式的CA证书文件
+ */
+func loadCertPool(filename string) (*x509.CertPool, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(content) {
+		return nil, fmt.Errorf("no certificate found in %s", filename)
+	}
+
+	return pool, nil
+}
+
+/**
+* 函数：listenAndServe
+* 功能：按Web配置启动HTTP或HTTPS服务，所有请求都经过认证
+ */
+func listenAndServe(address string, cfg *webConfig, handler http.Handler) error {
+	server := &http.Server{
+		Addr:    address,
+		Handler: newAuthHandler(cfg, handler),
+	}
+
+	t := cfg.TLSServerConfig
+	if t == nil {
+		return server.ListenAndServe()
+	}
+
+	reloader := &certificateReloader{certFile: t.CertFile, keyFile: t.KeyFile}
+
+	server.TLSConfig = &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.getCertificate,
+	}
+
+	if t.ClientCAFile != "" {
+		pool, err := loadCertPool(t.ClientCAFile)
+		if err != nil {
+			return err
+		}
+
+		server.TLSConfig.ClientCAs = pool
+		server.TLSConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return server.ListenAndServeTLS("", "")
+}
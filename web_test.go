@@ -0,0 +1,156 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestAuthHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hdw_exporter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tokenFile := filepath.Join(dir, "token")
+	if err = ioutil.WriteFile(tokenFile, []byte("file-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &webConfig{
+		BasicAuthUsers:   map[string]string{"prometheus": string(hash)},
+		BearerTokens:     []string{"static-token"},
+		BearerTokenFiles: []string{tokenFile},
+	}
+
+	handler := newAuthHandler(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	cases := []struct {
+		name          string
+		user, pass    string
+		authorization string
+		expected      int
+	}{
+		{"no credentials", "", "", "", http.StatusUnauthorized},
+		{"valid password", "prometheus", "secret", "", http.StatusOK},
+		{"valid password cached", "prometheus", "secret", "", http.StatusOK},
+		{"wrong password", "prometheus", "wrong", "", http.StatusUnauthorized},
+		{"unknown user", "admin", "secret", "", http.StatusUnauthorized},
+		{"static token", "", "", "Bearer static-token", http.StatusOK},
+		{"file token", "", "", "Bearer file-token", http.StatusOK},
+		{"wrong token", "", "", "Bearer other", http.StatusUnauthorized},
+		{"empty token", "", "", "Bearer ", http.StatusUnauthorized},
+		{"token prefix", "", "", "Bearer static", http.StatusUnauthorized},
+		{"other scheme", "", "", "Token static-token", http.StatusUnauthorized},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if c.user != "" {
+				req.SetBasicAuth(c.user, c.pass)
+			}
+
+			if c.authorization != "" {
+				req.Header.Set("Authorization", c.authorization)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != c.expected {
+				t.Errorf("status = %d, expected %d", rec.Code, c.expected)
+			}
+
+			if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("WWW-Authenticate header is missing")
+			}
+		})
+	}
+
+	// token文件在每次认证时重新读取
+	if err = ioutil.WriteFile(tokenFile, []byte("rotated-token"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for token, expected := range map[string]int{"rotated-token": http.StatusOK, "file-token": http.StatusUnauthorized} {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != expected {
+			t.Errorf("token %q: status = %d, expected %d", token, rec.Code, expected)
+		}
+	}
+}
+
+func TestAuthHandlerDisabled(t *testing.T) {
+	handler := newAuthHandler(&webConfig{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, expected %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestLoadWebConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hdw_exporter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err = ioutil.WriteFile(filepath.Join(dir, "token"), []byte("token"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		content string
+		fail    bool
+	}{
+		{"relative token file", "bearer_token_files: [token]", false},
+		{"missing token file", "bearer_token_files: [missing]", true},
+		{"invalid bcrypt hash", "basic_auth_users:\n  prometheus: secret", true},
+		{"empty token", "bearer_tokens: ['']", true},
+		{"missing key file", "tls_server_config:\n  cert_file: cert.pem", true},
+		{"unknown field", "basic_auth: {}", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			filename := filepath.Join(dir, "web.yml")
+			if err := ioutil.WriteFile(filename, []byte(c.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := loadWebConfig(filename)
+			if (err != nil) != c.fail {
+				t.Fatalf("loadWebConfig() error = %v, expected fail %v", err, c.fail)
+			}
+
+			if err == nil && len(cfg.BearerTokenFiles) > 0 && !filepath.IsAbs(cfg.BearerTokenFiles[0]) {
+				t.Errorf("token file %q is not resolved", cfg.BearerTokenFiles[0])
+			}
+		})
+	}
+
+	if cfg, err := loadWebConfig(""); err != nil || cfg.authEnabled() || cfg.TLSServerConfig != nil {
+		t.Errorf("loadWebConfig(\"\") = %+v, %v", cfg, err)
+	}
+}